@@ -0,0 +1,207 @@
+package command
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	models "github.com/elos/x/models/proto"
+	"github.com/mitchellh/cli"
+)
+
+// AuthCommand contains the state necessary to implement the
+// 'elos auth' command.
+//
+// It implements the cli.Command interface
+type AuthCommand struct {
+	// UI is used to communicate (for IO) with the user
+	// It must not be nil.
+	UI cli.Ui
+
+	// Config is the elos command configuration block, the
+	// credentials of which are modified by the auth command
+	Config *Config
+}
+
+// Synopsis is a one-line, short summary of the 'auth' command.
+// It is guaranteed to be at most 50 characters
+func (c *AuthCommand) Synopsis() string {
+	return "Utility to manage your elos credentials"
+}
+
+// Help is the long-form help text for the 'auth' command.
+func (c *AuthCommand) Help() string {
+	helpText := `
+Usage:
+	elos auth [-force]
+	elos auth logout
+
+	Prompts for new credentials, verifies them against the
+	configured host and then saves them to the configuration.
+
+	The logout subcommand clears the saved credentials.
+
+Options:
+	-force		Save the credentials without verifying them,
+			useful when setting up offline
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Run runs the 'auth' command with the given command-line arguments.
+// It returns an exit status when it finishes. 0 indicates a success,
+// any other integer indicates a failure.
+//
+// All user interaction is handled by the command using the UI
+// interface
+func (c *AuthCommand) Run(args []string) int {
+	if c.UI == nil {
+		log.Print("(elos auth): no ui")
+		return failure
+	}
+
+	if c.Config == nil {
+		c.errorf("no config")
+		return failure
+	}
+
+	if len(args) > 0 && args[0] == "logout" {
+		return c.runLogout()
+	}
+
+	force := false
+	for _, a := range args {
+		switch a {
+		case "-force":
+			force = true
+		default:
+			c.errorf("unrecognized option %q", a)
+			c.UI.Output(c.Help())
+			return failure
+		}
+	}
+
+	return c.askCredentials(force)
+}
+
+// errorf calls UI.Error with a formatted, prefixed error string
+// always use it to print an error, avoid using UI.Error directly
+func (c *AuthCommand) errorf(format string, values ...interface{}) {
+	c.UI.Error(fmt.Sprintf("(elos auth) Error: "+format, values...))
+}
+
+// printf calls UI.Output with the formmated string
+// always prefer printf over c.UI.Output
+func (c *AuthCommand) printf(format string, values ...interface{}) {
+	c.UI.Output(fmt.Sprintf(format, values...))
+}
+
+// askCredentials prompts for the public and private credentials and
+// the id of the user they belong to, and saves them to the config.
+// Unless force is true, the credentials are only saved if the host
+// accepts them.
+func (c *AuthCommand) askCredentials(force bool) int {
+	var public, private, id string
+	var err error
+
+	if public, err = stringInput(c.UI, "Public credential"); err != nil {
+		c.errorf("input: %s", err)
+		return failure
+	}
+
+	if private, err = stringInput(c.UI, "Private credential"); err != nil {
+		c.errorf("input: %s", err)
+		return failure
+	}
+
+	if id, err = stringInput(c.UI, "User ID"); err != nil {
+		c.errorf("input: %s", err)
+		return failure
+	}
+
+	if !force {
+		if err := verifyCredentials(c.Config.Host, public, private, id); err != nil {
+			c.UI.Warn(fmt.Sprintf("Could not verify credentials with %s: %s", c.Config.Host, err))
+			c.UI.Warn("Credentials not saved, use -force to save them anyway")
+			return failure
+		}
+	}
+
+	c.Config.PublicCredential = public
+	c.Config.PrivateCredential = private
+	c.Config.UserID = id
+	if err := WriteConfigFile(c.Config); err != nil {
+		c.errorf("failed to persist configuration change: %s", err)
+		return failure
+	}
+
+	c.printf("Saved credentials for user %s", id)
+	return success
+}
+
+// runLogout clears the saved credentials, and optionally the user
+// id, after confirming with the user.
+func (c *AuthCommand) runLogout() int {
+	if sure, err := yesNo(c.UI, "Are you sure you want to clear your credentials?"); err != nil {
+		c.errorf("input: %s", err)
+		return failure
+	} else if !sure {
+		c.printf("Cancelled")
+		return success
+	}
+
+	clearID, err := yesNo(c.UI, "Would you like to clear your user id as well?")
+	if err != nil {
+		c.errorf("input: %s", err)
+		return failure
+	}
+
+	c.Config.PublicCredential = ""
+	c.Config.PrivateCredential = ""
+	c.Config.Credential.Public = ""
+	c.Config.Credential.Private = ""
+	if clearID {
+		c.Config.UserID = ""
+		c.Config.Credential.OwnerID = ""
+	}
+
+	if err := WriteConfigFile(c.Config); err != nil {
+		c.errorf("failed to persist configuration change: %s", err)
+		return failure
+	}
+
+	c.printf("Logged out")
+	return success
+}
+
+// verifyCredentials checks the credentials against the host, by
+// making an authenticated request for the user they belong to.
+func verifyCredentials(host, public, private, id string) error {
+	params := url.Values{}
+	params.Set("kind", models.Kind_USER.String())
+	params.Set("id", id)
+	req, err := http.NewRequest("GET", host+"/record/?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(public, private)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("credentials rejected")
+	case http.StatusNotFound:
+		return fmt.Errorf("no user with id %s", id)
+	default:
+		return fmt.Errorf("bad status code on GET to /record/: %d", resp.StatusCode)
+	}
+}
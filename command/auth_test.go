@@ -0,0 +1,200 @@
+package command_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/elos/elos/command"
+	"github.com/mitchellh/cli"
+)
+
+func newMockAuthCommand(t *testing.T) (*cli.MockUi, *command.Config, *command.AuthCommand) {
+	f, err := ioutil.TempFile("", "conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	ui := new(cli.MockUi)
+	c := &command.Config{
+		Path: f.Name(),
+	}
+
+	return ui, c, &command.AuthCommand{
+		UI:     ui,
+		Config: c,
+	}
+}
+
+// newTestAuthHost returns a host which only accepts the
+// credentials "public" and "private"
+func newTestAuthHost() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != "public" || p != "private" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}))
+}
+
+// --- `elos auth` {{{
+func TestAuth(t *testing.T) {
+	s := newTestAuthHost()
+	defer s.Close()
+
+	ui, conf, c := newMockAuthCommand(t)
+	defer os.Remove(conf.Path)
+	conf.Host = s.URL
+	conf.Credential.Public = "grpc-public"
+
+	ui.InputReader = bytes.NewBufferString("public\nprivate\n1\n")
+
+	t.Log("running: `elos auth`")
+	code := c.Run([]string{})
+	t.Log("command `auth` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	if code != 0 {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	written, err := command.ParseConfigFile(conf.Path)
+	if err != nil {
+		t.Fatalf("ParseConfigFile error: %s", err)
+	}
+
+	if written.PublicCredential != "public" || written.PrivateCredential != "private" || written.UserID != "1" {
+		t.Fatalf("Expected credentials to be saved, got: %+v", written)
+	}
+
+	// the grpc credential is set separately
+	if written.Credential.Public != "grpc-public" {
+		t.Fatalf("Expected the grpc credential to be unchanged, got: %+v", written.Credential)
+	}
+}
+
+// --- }}}
+
+// --- `elos auth` (rejected) {{{
+func TestAuthRejected(t *testing.T) {
+	s := newTestAuthHost()
+	defer s.Close()
+
+	ui, conf, c := newMockAuthCommand(t)
+	defer os.Remove(conf.Path)
+	conf.Host = s.URL
+
+	ui.InputReader = bytes.NewBufferString("public\nwrong\n1\n")
+
+	t.Log("running: `elos auth`")
+	code := c.Run([]string{})
+	t.Log("command `auth` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	if code == 0 {
+		t.Fatalf("Expected failure exit code")
+	}
+
+	if !strings.Contains(errput, "credentials rejected") {
+		t.Fatalf("Error output should have said the credentials were rejected")
+	}
+
+	if conf.PublicCredential != "" || conf.PrivateCredential != "" || conf.UserID != "" {
+		t.Fatalf("Credentials should not have been saved, got: %+v", conf)
+	}
+}
+
+// --- }}}
+
+// --- `elos auth -force` {{{
+func TestAuthForce(t *testing.T) {
+	s := newTestAuthHost()
+	defer s.Close()
+
+	ui, conf, c := newMockAuthCommand(t)
+	defer os.Remove(conf.Path)
+	conf.Host = s.URL
+
+	ui.InputReader = bytes.NewBufferString("public\nwrong\n1\n")
+
+	t.Log("running: `elos auth -force`")
+	code := c.Run([]string{"-force"})
+	t.Log("command `auth` terminated")
+
+	errput := ui.ErrorWriter.String()
+	t.Logf("Error output:\n%s", errput)
+
+	if code != 0 {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	if conf.PrivateCredential != "wrong" {
+		t.Fatalf("Expected the unverified credentials to be saved, got: %+v", conf)
+	}
+}
+
+// --- }}}
+
+// --- `elos auth logout` {{{
+func TestAuthLogout(t *testing.T) {
+	ui, conf, c := newMockAuthCommand(t)
+	defer os.Remove(conf.Path)
+	conf.PublicCredential = "public"
+	conf.PrivateCredential = "private"
+	conf.UserID = "1"
+	conf.Credential.Public = "public"
+	conf.Credential.Private = "private"
+	conf.Credential.OwnerID = "1"
+
+	// yes sure, yes clear the user id too
+	ui.InputReader = bytes.NewBufferString("y\ny\n")
+
+	t.Log("running: `elos auth logout`")
+	code := c.Run([]string{"logout"})
+	t.Log("command `auth logout` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	if code != 0 {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	written, err := command.ParseConfigFile(conf.Path)
+	if err != nil {
+		t.Fatalf("ParseConfigFile error: %s", err)
+	}
+
+	if written.PublicCredential != "" || written.PrivateCredential != "" || written.UserID != "" {
+		t.Fatalf("Expected credentials to be cleared, got: %+v", written)
+	}
+
+	if written.Credential.Public != "" || written.Credential.Private != "" || written.Credential.OwnerID != "" {
+		t.Fatalf("Expected grpc credentials to be cleared, got: %+v", written.Credential)
+	}
+}
+
+// --- }}}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -87,7 +88,7 @@ func (c *CalCommand) Run(args []string) int {
 		case "weekday":
 			return c.runSchedulingWeekday(args)
 		case "yearday":
-			c.UI.Output("elos cal scheduling yearday not implemented yet")
+			return c.runSchedulingYearday(args)
 		}
 	}
 
@@ -102,17 +103,125 @@ func (c *CalCommand) runNext(args []string) int {
 	return success
 }
 
+// runToday runs the 'today' subcommand, which lists the fixtures
+// for today, each annotated with the schedule it comes from.
 func (c *CalCommand) runToday(args []string) int {
-	fixtures, err := c.cal.FixturesForDate(time.Now(), c.DB)
+	fixtures, sources, err := c.fixturesForDate(time.Now())
 	if err != nil {
 		c.UI.Error(err.Error())
 		return failure
 	}
 
-	printFixtures(c.UI, fixtures)
+	if len(fixtures) == 0 {
+		c.UI.Output(" -- No fixtures")
+		return success
+	}
+
+	sort.Sort(byStartTime(fixtures))
+	for _, f := range fixtures {
+		c.UI.Output(fmt.Sprintf("%s (%s)", fixtureLine(f), sources[f]))
+	}
 	return success
 }
 
+// fixturesForDate layers the fixtures of the base schedule, the schedule
+// for the date's weekday and the schedule for the date's day of the year.
+//
+// Conflicts are resolved so that yearday beats weekday beats base: a
+// fixture is dropped if it overlaps one from a more specific schedule.
+// Labels never conflict. The second return value maps each fixture to
+// the name of the schedule it came from.
+func (c *CalCommand) fixturesForDate(t time.Time) ([]*models.Fixture, map[*models.Fixture]string, error) {
+	var baseFixtures []*models.Fixture
+	base, err := c.cal.BaseSchedule(c.DB)
+	switch err {
+	case nil:
+		if baseFixtures, err = base.Fixtures(c.DB); err != nil {
+			return nil, nil, err
+		}
+	case models.ErrEmptyLink:
+	default:
+		return nil, nil, err
+	}
+
+	weekdayFixtures, err := c.scheduleFixtures(c.cal.WeekdaySchedules[weekdayKey(int(t.Weekday()))])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	yeardayFixtures, err := c.scheduleFixtures(c.cal.YeardaySchedules[yeardayKey(int(t.Month()), t.Day())])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// most specific first
+	layers := []struct {
+		source   string
+		fixtures []*models.Fixture
+	}{
+		{"yearday", yeardayFixtures},
+		{"weekday", weekdayFixtures},
+		{"base", baseFixtures},
+	}
+
+	fixtures := make([]*models.Fixture, 0)
+	sources := make(map[*models.Fixture]string)
+	for _, layer := range layers {
+		// only compare against more specific layers, not this one
+		higher := fixtures
+	Fixtures:
+		for _, f := range layer.fixtures {
+			if !f.Label {
+				for _, h := range higher {
+					if !h.Label && fixturesOverlap(f, h) {
+						continue Fixtures
+					}
+				}
+			}
+
+			fixtures = append(fixtures, f)
+			sources[f] = layer.source
+		}
+	}
+
+	return fixtures, sources, nil
+}
+
+// scheduleFixtures retrieves the fixtures of the schedule with the given
+// id. An empty id, meaning there is no such schedule, has no fixtures.
+func (c *CalCommand) scheduleFixtures(id string) ([]*models.Fixture, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	schedule := models.NewSchedule()
+	schedule.Id = id
+	if err := c.DB.PopulateByID(schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule.Fixtures(c.DB)
+}
+
+// minutesOfDay is the number of minutes since midnight of the time
+func minutesOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+// fixturesOverlap checks whether the fixtures' times of day overlap,
+// fixtures only make use of the hour and minute of their times.
+func fixturesOverlap(a, b *models.Fixture) bool {
+	return minutesOfDay(a.StartTime) < minutesOfDay(b.EndTime) && minutesOfDay(b.StartTime) < minutesOfDay(a.EndTime)
+}
+
+// fixtureInProgress checks whether the time of day of now is within
+// the fixture's, fixtures only make use of the hour and minute of
+// their times, as they recur.
+func fixtureInProgress(f *models.Fixture, now time.Time) bool {
+	m := minutesOfDay(now)
+	return minutesOfDay(f.StartTime) <= m && m < minutesOfDay(f.EndTime)
+}
+
 func (c *CalCommand) newSchedule(name string) *models.Schedule {
 	base := models.NewSchedule()
 	base.SetID(c.DB.NewID())
@@ -171,7 +280,7 @@ func (c *CalCommand) runSchedulingWeekday(args []string) int {
 		}
 	}
 
-	scheduleID, ok := c.cal.WeekdaySchedules[string(i)]
+	scheduleID, ok := c.cal.WeekdaySchedules[weekdayKey(i)]
 	schedule := models.NewSchedule()
 	if ok {
 		schedule.Id = scheduleID
@@ -188,7 +297,7 @@ func (c *CalCommand) runSchedulingWeekday(args []string) int {
 			return failure
 		}
 
-		c.cal.WeekdaySchedules[string(i)] = weekday.Id
+		c.cal.WeekdaySchedules[weekdayKey(i)] = weekday.Id
 
 		if err = c.DB.Save(c.cal); err != nil {
 			c.UI.Error(err.Error())
@@ -226,6 +335,100 @@ func (c *CalCommand) runSchedulingWeekday(args []string) int {
 	return success
 }
 
+func (c *CalCommand) runSchedulingYearday(args []string) int {
+	var month, day int
+	var err error
+	for !validYearday(month, day) {
+		if month, err = intInput(c.UI, "For which month? (e.g., 1 for January)"); err != nil {
+			c.UI.Error(fmt.Sprintf("Error with input: %s", err))
+			return failure
+		}
+
+		if day, err = intInput(c.UI, "For which day of the month?"); err != nil {
+			c.UI.Error(fmt.Sprintf("Error with input: %s", err))
+			return failure
+		}
+	}
+
+	scheduleID, ok := c.cal.YeardaySchedules[yeardayKey(month, day)]
+	schedule := models.NewSchedule()
+	if ok {
+		schedule.Id = scheduleID
+		if err := c.DB.PopulateByID(schedule); err != nil {
+			c.UI.Error(fmt.Sprintf("Error populating yearday schedule: %s", err))
+			return 1
+		}
+	} else {
+		c.UI.Output("Looks like you don't have a schedule for that day, creating one now...")
+		yearday := c.newSchedule("Yearday Schedule")
+
+		if err := c.DB.Save(yearday); err != nil {
+			c.UI.Error(err.Error())
+			return failure
+		}
+
+		c.cal.YeardaySchedules[yeardayKey(month, day)] = yearday.Id
+
+		if err = c.DB.Save(c.cal); err != nil {
+			c.UI.Error(err.Error())
+			return failure
+		}
+
+		schedule = yearday
+	}
+
+	fixtures, err := schedule.Fixtures(c.DB)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error retrieving the fixtures of your yearday schedule %s", err))
+		return 1
+	}
+	c.UI.Output(fmt.Sprintf("%s %d Schedule Fixtures:", time.Month(month), day))
+	printFixtures(c.UI, fixtures)
+
+	b, err := yesNo(c.UI, "Would you like to add a fixture now?")
+	if err != nil {
+		return 1
+	}
+
+	if b {
+		f, err := createFixture(c.UI, c.UserID, c.DB)
+		if err != nil {
+			return 1
+		}
+
+		schedule.IncludeFixture(f)
+		err = c.DB.Save(schedule)
+		if err != nil {
+			return 1
+		}
+	}
+	return success
+}
+
+// weekdayKey is the key of the given weekday's schedule in
+// a calendar's WeekdaySchedules, i.e., "1" for Monday.
+func weekdayKey(i int) string {
+	return strconv.Itoa(i)
+}
+
+// yeardayKey is the key of the given day's schedule in a
+// calendar's YeardaySchedules, i.e., "01-31" for January 31st.
+func yeardayKey(month, day int) string {
+	return fmt.Sprintf("%02d-%02d", month, day)
+}
+
+// validYearday checks that the month and day make a day of the
+// year. February 29th is valid, as it is a day in leap years.
+func validYearday(month, day int) bool {
+	if month < 1 || month > 12 || day < 1 {
+		return false
+	}
+
+	// 2000 was a leap year, time.Date normalizes overflowing days
+	// into the next month
+	return time.Date(2000, time.Month(month), day, 0, 0, 0, 0, time.UTC).Month() == time.Month(month)
+}
+
 type byStartTime []*models.Fixture
 
 // Len is the number of elements in the collection.
@@ -250,20 +453,36 @@ func printFixtures(ui cli.Ui, fixtures []*models.Fixture) {
 		return
 	}
 	sort.Sort(byStartTime(fixtures))
+	now := time.Now()
 	for _, f := range fixtures {
-		var output string
-		if f.Label {
-			output = fmt.Sprintf("* %s [Label]", f.Name)
-		} else {
-			output = fmt.Sprintf(`
-			 * %s [%s - %s]
-			`, f.Name, f.StartTime.Format("15:04"), f.EndTime.Format("15:04"))
+		line := fixtureLine(f)
+
+		// labels are dim, and fixtures in progress green
+		switch {
+		case f.Label:
+			line = colorize(colorDim, line)
+		case fixtureInProgress(f, now):
+			line = colorize(colorGreen, line)
 		}
 
-		ui.Output(strings.TrimSpace(output))
+		ui.Output(line)
 	}
 }
 
+// fixtureLine formats a fixture for listing
+func fixtureLine(f *models.Fixture) string {
+	var output string
+	if f.Label {
+		output = fmt.Sprintf("* %s [Label]", f.Name)
+	} else {
+		output = fmt.Sprintf(`
+		 * %s [%s - %s]
+		`, f.Name, f.StartTime.Format("15:04"), f.EndTime.Format("15:04"))
+	}
+
+	return strings.TrimSpace(output)
+}
+
 func createFixture(ui cli.Ui, ownerID string, db data.DB) (fixture *models.Fixture, err error) {
 	ui.Output("Creating a fixture")
 
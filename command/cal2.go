@@ -11,6 +11,9 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -47,6 +50,10 @@ type Cal2Command struct {
 
 	// The client to the database
 	data.DBClient
+
+	// Location is the location times are displayed in.
+	// If nil, time.Local is used
+	Location *time.Location
 }
 
 func (c *Cal2Command) Synopsis() string {
@@ -58,9 +65,23 @@ Usage:
 	elos cal2 <subcommand>
 
 Subcommands:
-	day		list the events for today
-	week	list the events for this week
-	google	sync with google
+	day [date]		list the events for today (or date)
+	new			create a new event
+	next			list the next upcoming event
+	now			list the event currently underway
+	week [-from date]	list the events for this week (or from date)
+	google			sync with google
+
+Google Options:
+	-since <Nd|date>	sync events since N days ago, or date (default 30d)
+	-until <date>		sync events until date (default +90d)
+	-calendar <id>		sync the calendar with the id (default primary)
+	-calendars		list the calendars available to sync
+	-timeout <duration>	give up syncing after the duration (default 10m)
+	--dry-run		print what the sync would create, update and delete
+
+Dates are of the form YYYY-MM-DD, or one of today, tomorrow,
+yesterday and +/-Nd (e.g., +3d for three days from now).
 `
 }
 
@@ -77,6 +98,12 @@ func (c *Cal2Command) Run(args []string) int {
 		return c.runWeek(args[1:])
 	case "google":
 		return c.runGoogle(args[1:])
+	case "new":
+		return c.runNew(args[1:])
+	case "next":
+		return c.runNext(args[1:])
+	case "now":
+		return c.runNow(args[1:])
 	default:
 		c.UI.Output(c.Help())
 		return success
@@ -92,6 +119,172 @@ func (c *Cal2Command) runWeek(args []string) int {
 }
 
 func (c *Cal2Command) runListDays(args []string, num int) int {
+	// days begin and end in the location the events are displayed in
+	from := time.Now().In(c.location())
+	if len(args) > 0 {
+		arg := args[0]
+		if arg == "-from" {
+			if len(args) < 2 {
+				c.UI.Error("-from requires a date")
+				return failure
+			}
+			arg = args[1]
+		}
+
+		d, err := parseDate(arg, from)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return failure
+		}
+		from = d
+	}
+
+	fixtures, err := c.fixtures()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return failure
+	}
+
+	firstDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	if num == 1 {
+		es := cal.EventsWithin(firstDay, firstDay.AddDate(0, 0, 1), fixtures)
+		for _, e := range es {
+			c.UI.Output(eventLine(e.Name, e.Start.Time(), e.End.Time(), c.location()))
+		}
+		return 0
+	}
+
+	// more than one day is grouped by day, under a header
+	for i := 0; i < num; i++ {
+		day := firstDay.AddDate(0, 0, i)
+		c.UI.Output(day.Format(dayHeaderFormat))
+
+		es := cal.EventsWithin(day, day.AddDate(0, 0, 1), fixtures)
+		if len(es) == 0 {
+			c.UI.Output(" — nothing")
+			continue
+		}
+
+		for _, e := range es {
+			c.UI.Output(eventLine(e.Name, e.Start.Time(), e.End.Time(), c.location()))
+		}
+	}
+	return 0
+}
+
+// dayHeaderFormat is the format of the header of each day,
+// when listing the events of several days, i.e., "Monday Jan 6"
+const dayHeaderFormat = "Monday Jan 2"
+
+// runNew prompts for the name, start and end of an event, and
+// creates a fixture for it.
+func (c *Cal2Command) runNew(args []string) int {
+	name, err := stringInput(c.UI, "Name of the event:")
+	if err != nil {
+		c.UI.Error(err.Error())
+		return failure
+	}
+
+	start, err := dateInputIn(c.UI, "Start of the event?", c.location())
+	if err != nil {
+		c.UI.Error(err.Error())
+		return failure
+	}
+
+	end, err := timeInputIn(c.UI, "End of the event?", c.location())
+	if err != nil {
+		c.UI.Error(err.Error())
+		return failure
+	}
+
+	// the event ends on the day it starts
+	end = time.Date(start.Year(), start.Month(), start.Day(), end.Hour(), end.Minute(), 0, 0, c.location())
+	if !end.After(start) {
+		c.UI.Error("the event must end after it starts")
+		return failure
+	}
+
+	now := models.TimestampFrom(time.Now())
+	rec, err := c.DBClient.Mutate(context.Background(), &data.Mutation{
+		Op: data.Mutation_CREATE,
+		Record: &data.Record{
+			Kind: models.Kind_FIXTURE,
+			Fixture: &models.Fixture{
+				OwnerId:   c.UserID,
+				Name:      name,
+				StartTime: models.TimestampFrom(start),
+				EndTime:   models.TimestampFrom(end),
+				CreatedAt: now,
+				UpdatedAt: now,
+			},
+		},
+	})
+	if err != nil {
+		c.UI.Error(err.Error())
+		return failure
+	}
+
+	c.UI.Output("Created" + eventLine(rec.Fixture.Name, start, end, c.location()))
+	return success
+}
+
+// runNow prints the event which is currently underway, that
+// is, the one which started at or before now, and has not ended.
+func (c *Cal2Command) runNow(args []string) int {
+	fixtures, err := c.fixtures()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return failure
+	}
+
+	now := time.Now()
+	printed := false
+	for _, e := range cal.EventsWithin(now.AddDate(0, 0, -1), now.AddDate(0, 0, 1), fixtures) {
+		if !e.Start.Time().After(now) && now.Before(e.End.Time()) {
+			c.UI.Output(eventLine(e.Name, e.Start.Time(), e.End.Time(), c.location()))
+			printed = true
+		}
+	}
+
+	if !printed {
+		c.UI.Output("Nothing scheduled right now")
+	}
+	return success
+}
+
+// runNext prints the next event to start, looking a week ahead.
+func (c *Cal2Command) runNext(args []string) int {
+	fixtures, err := c.fixtures()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return failure
+	}
+
+	now := time.Now()
+	es := cal.EventsWithin(now, now.AddDate(0, 0, 7), fixtures)
+
+	next := -1
+	for i, e := range es {
+		if !e.Start.Time().After(now) {
+			continue
+		}
+
+		if next < 0 || e.Start.Time().Before(es[next].Start.Time()) {
+			next = i
+		}
+	}
+
+	if next < 0 {
+		c.UI.Output("Nothing scheduled in the next week")
+		return success
+	}
+
+	c.UI.Output(eventLine(es[next].Name, es[next].Start.Time(), es[next].End.Time(), c.location()))
+	return success
+}
+
+// fixtures retrieves all of the user's fixtures.
+func (c *Cal2Command) fixtures() ([]*models.Fixture, error) {
 	results, err := c.DBClient.Query(context.Background(), &data.Query{
 		Kind: models.Kind_FIXTURE,
 		Filters: []*data.Filter{
@@ -106,8 +299,7 @@ func (c *Cal2Command) runListDays(args []string, num int) int {
 		},
 	})
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("w.db.Query error: %v", err))
-		return 1
+		return nil, fmt.Errorf("w.db.Query error: %v", err)
 	}
 
 	fixtures := make([]*models.Fixture, 0)
@@ -117,35 +309,53 @@ func (c *Cal2Command) runListDays(args []string, num int) int {
 			break
 		}
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("results.Recv error: %v", err))
-			return 1
+			return nil, fmt.Errorf("results.Recv error: %v", err)
 		}
 		fixtures = append(fixtures, rec.Fixture)
 	}
 
-	firstDay := cal.DateFrom(time.Now())
-	es := cal.EventsWithin(firstDay.Time(), firstDay.Time().AddDate(0, 0, num), fixtures)
-	for _, e := range es {
-		c.UI.Output(fmt.Sprintf(" - %s [%s-%s]", e.Name, e.Start.Time().Local().Format(time.Kitchen), e.End.Time().Local().Format(time.Kitchen)))
-	}
-	return 0
+	return fixtures, nil
 }
 
-func ingestEvent(ctx context.Context, dbc data.DBClient, uid string, e *calendar.Event) (*models.Fixture, error) {
-	log.Printf("ingesting %s", e.Summary)
-	f, err := models.UnmarshalGoogleEvent(e)
-	if err != nil {
-		return nil, err
+// location returns the location times are displayed in
+func (c *Cal2Command) location() *time.Location {
+	if c.Location == nil {
+		return time.Local
 	}
+
+	return c.Location
+}
+
+// eventLine formats an event for listing, in the given location.
+func eventLine(name string, start, end time.Time, loc *time.Location) string {
+	return fmt.Sprintf(" - %s [%s-%s]", name, start.In(loc).Format(time.Kitchen), end.In(loc).Format(time.Kitchen))
+}
+
+// googleEventLabel is the fixture label which holds the id of the
+// google calendar event a fixture was ingested from. Only fixtures
+// ingested from google carry it.
+const googleEventLabel = "google/event/id"
+
+// findGoogleFixture looks up the user's fixture which was ingested from the
+// google event with the given id. It returns a nil fixture if there is none.
+func findGoogleFixture(ctx context.Context, dbc data.DBClient, uid, eventID string) (*models.Fixture, error) {
 	results, err := dbc.Query(ctx, &data.Query{
 		Kind: models.Kind_FIXTURE,
 		Filters: []*data.Filter{
 			&data.Filter{
 				Op:    data.Filter_EQ,
-				Field: "labels.google/event/id",
+				Field: "owner_id",
 				Reference: &models.Value{
 					Type:    models.Value_STRING,
-					String_: e.Id,
+					String_: uid,
+				},
+			},
+			&data.Filter{
+				Op:    data.Filter_EQ,
+				Field: "labels." + googleEventLabel,
+				Reference: &models.Value{
+					Type:    models.Value_STRING,
+					String_: eventID,
 				},
 			},
 		},
@@ -165,36 +375,245 @@ func ingestEvent(ctx context.Context, dbc data.DBClient, uid string, e *calendar
 	}
 
 	if err == io.EOF {
+		return nil, nil
+	}
+
+	return rec.Fixture, nil
+}
+
+// planEvent decides how to ingest the given google event: it returns
+// the mutation which creates a fixture for the event, or updates the
+// fixture which was already ingested from it.
+func planEvent(ctx context.Context, dbc data.DBClient, uid string, e *calendar.Event) (*data.Mutation, error) {
+	f, err := models.UnmarshalGoogleEvent(e)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := findGoogleFixture(ctx, dbc, uid, e.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	op := data.Mutation_UPDATE
+	if existing == nil {
 		f.OwnerId = uid
-		if rec, err = dbc.Mutate(ctx, &data.Mutation{
-			Op: data.Mutation_CREATE,
-			Record: &data.Record{
-				Kind:    models.Kind_FIXTURE,
-				Fixture: f,
-			},
-		}); err != nil {
-			return nil, err
-		} else {
-			return rec.Fixture, nil
-		}
+		op = data.Mutation_CREATE
 	} else {
-		f.Id = rec.Fixture.Id
-		if rec, err = dbc.Mutate(ctx, &data.Mutation{
-			Op: data.Mutation_UPDATE,
-			Record: &data.Record{
-				Kind:    models.Kind_FIXTURE,
-				Fixture: f,
-			},
-		}); err != nil {
+		f.Id = existing.Id
+	}
+
+	return &data.Mutation{
+		Op: op,
+		Record: &data.Record{
+			Kind:    models.Kind_FIXTURE,
+			Fixture: f,
+		},
+	}, nil
+}
+
+func ingestEvent(ctx context.Context, dbc data.DBClient, uid string, e *calendar.Event) (*models.Fixture, error) {
+	log.Printf("ingesting %s", e.Summary)
+	m, err := planEvent(ctx, dbc, uid, e)
+	if err != nil {
+		return nil, err
+	}
+
+	rec, err := dbc.Mutate(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	return rec.Fixture, nil
+}
+
+// planRemoval decides how to remove the given, cancelled, google event:
+// it returns the mutation which deletes the fixture which was ingested
+// from it, or nil if there is no such fixture.
+//
+// Only fixtures carrying the googleEventLabel are ever considered, so
+// fixtures created in elos are never deleted.
+func planRemoval(ctx context.Context, dbc data.DBClient, uid string, e *calendar.Event) (*data.Mutation, error) {
+	f, err := findGoogleFixture(ctx, dbc, uid, e.Id)
+	if err != nil || f == nil {
+		return nil, err
+	}
+
+	return &data.Mutation{
+		Op: data.Mutation_DELETE,
+		Record: &data.Record{
+			Kind:    models.Kind_FIXTURE,
+			Fixture: f,
+		},
+	}, nil
+}
+
+// removeEvent deletes the fixture which was ingested from the given,
+// cancelled, google event. It returns whether there was such a fixture.
+func removeEvent(ctx context.Context, dbc data.DBClient, uid string, e *calendar.Event) (bool, error) {
+	m, err := planRemoval(ctx, dbc, uid, e)
+	if err != nil || m == nil {
+		return false, err
+	}
+
+	log.Printf("removing %s", m.Record.Fixture.Name)
+	if _, err := dbc.Mutate(ctx, m); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// planMasterRemovals returns the mutations which delete the fixtures
+// ingested from the recurring events, rather than their instances, of
+// the given events. Those were ingested before sync expanded recurring
+// events, and would otherwise show up alongside the instances.
+func planMasterRemovals(ctx context.Context, dbc data.DBClient, uid string, events []*calendar.Event) ([]*data.Mutation, error) {
+	seen := make(map[string]bool)
+	ms := make([]*data.Mutation, 0)
+	for _, e := range events {
+		if e.RecurringEventId == "" || seen[e.RecurringEventId] {
+			continue
+		}
+		seen[e.RecurringEventId] = true
+
+		m, err := planRemoval(ctx, dbc, uid, &calendar.Event{Id: e.RecurringEventId})
+		if err != nil {
 			return nil, err
-		} else {
-			return rec.Fixture, nil
+		}
+
+		if m != nil {
+			ms = append(ms, m)
+		}
+	}
+
+	return ms, nil
+}
+
+// removeMasters deletes the fixtures ingested from the recurring
+// events of the given events, see planMasterRemovals. It returns
+// the number of fixtures deleted.
+func removeMasters(ctx context.Context, dbc data.DBClient, uid string, events []*calendar.Event) (int, error) {
+	ms, err := planMasterRemovals(ctx, dbc, uid, events)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, m := range ms {
+		log.Printf("removing %s, now ingested as its instances", m.Record.Fixture.Name)
+		if _, err := dbc.Mutate(ctx, m); err != nil {
+			return 0, err
 		}
 	}
+
+	return len(ms), nil
+}
+
+// googleOptions are the options accepted by the 'google' subcommand
+type googleOptions struct {
+	// since is the start of the window of events to sync
+	since time.Time
+
+	// until is the end of the window of events to sync, it bounds
+	// the expansion of recurring events
+	until time.Time
+
+	// calendar is the id of the google calendar to sync
+	calendar string
+
+	// listCalendars indicates to list the calendars, rather than sync
+	listCalendars bool
+
+	// timeout bounds the time taken to fetch and sync the events
+	timeout time.Duration
+
+	// dryRun indicates to print what the sync would do, rather
+	// than changing any fixtures
+	dryRun bool
+}
+
+// parseGoogleOptions parses the arguments to the 'google' subcommand,
+// filling in the defaults of the primary calendar and a one month window.
+func parseGoogleOptions(args []string, now time.Time) (*googleOptions, error) {
+	opts := &googleOptions{
+		since:    now.AddDate(0, -1, 0),
+		until:    now.AddDate(0, 0, 90),
+		calendar: "primary",
+		timeout:  10 * time.Minute,
+	}
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-calendars":
+			opts.listCalendars = true
+		case "-dry-run", "--dry-run":
+			opts.dryRun = true
+		case "-calendar":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("-calendar requires a calendar id")
+			}
+			i++
+			opts.calendar = args[i]
+		case "-since":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("-since requires a number of days or a date")
+			}
+			i++
+			since, err := parseSince(args[i], now)
+			if err != nil {
+				return nil, err
+			}
+			opts.since = since
+		case "-until":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("-until requires a date")
+			}
+			i++
+			until, err := parseDate(args[i], now)
+			if err != nil {
+				return nil, err
+			}
+			opts.until = until
+		case "-timeout":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("-timeout requires a duration")
+			}
+			i++
+			d, err := time.ParseDuration(args[i])
+			if err != nil {
+				return nil, err
+			}
+			if d <= 0 {
+				return nil, fmt.Errorf("-timeout must be positive")
+			}
+			opts.timeout = d
+		default:
+			return nil, fmt.Errorf("unrecognized option %q", args[i])
+		}
+	}
+
+	return opts, nil
+}
+
+// parseSince parses a lookback window, either a number of days
+// (i.e., 30d) before now, or a date understood by parseDate.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if strings.HasSuffix(s, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(s, "d")); err == nil && days >= 0 {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+
+	return parseDate(s, now)
 }
 
 func (c *Cal2Command) runGoogle(args []string) int {
-	ctx, _ := context.WithTimeout(context.Background(), 1*time.Minute)
+	opts, err := parseGoogleOptions(args, time.Now())
+	if err != nil {
+		c.UI.Error(err.Error())
+		return failure
+	}
+
 	config, err := google.ConfigFromJSON([]byte(clientSecret), calendar.CalendarScope)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("unable to parse client secrete file to config: %v", err))
@@ -207,52 +626,201 @@ func (c *Cal2Command) runGoogle(args []string) int {
 		return failure
 	}
 
-	client := getClient(ctx, config, u)
+	// the timeout starts once authorized, so the time taken
+	// authorizing elos in the browser doesn't count against it
+	client := getClient(context.Background(), config, u)
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
+
 	srv, err := calendar.New(client)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("unable to retrieve calendar client %v", err))
 		return failure
 	}
-	events, err := srv.Events.List("primary").
-		ShowDeleted(false).
+
+	if opts.listCalendars {
+		cl, err := srv.CalendarList.List().Do()
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("unable to retrieve calendar list: %v", err))
+			return failure
+		}
+
+		for _, i := range cl.Items {
+			primary := ""
+			if i.Primary {
+				primary = " [primary]"
+			}
+			c.UI.Output(fmt.Sprintf(" - %s (%s)%s", i.Summary, i.Id, primary))
+		}
+		return success
+	}
+
+	// SingleEvents(true) has google expand each recurring event into
+	// its instances within [since, until). Every instance is ingested
+	// as its own fixture, keyed by the instance's event id, so the
+	// recurrence rule itself is never stored on a fixture and each
+	// occurrence shows up in 'elos cal2 week'. A fixture ingested
+	// from the recurring event itself is removed by the sync.
+	events := make([]*calendar.Event, 0)
+	err = srv.Events.List(opts.calendar).
+		ShowDeleted(true).
 		SingleEvents(true).
-		TimeMin(time.Now().AddDate(0, -1, 0).Format(time.RFC3339)).
-		OrderBy("startTime").Do()
+		TimeMin(opts.since.Format(time.RFC3339)).
+		TimeMax(opts.until.Format(time.RFC3339)).
+		OrderBy("startTime").
+		Pages(ctx, func(page *calendar.Events) error {
+			events = append(events, page.Items...)
+			return nil
+		})
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("unable to retrieve user events: $v", err))
+		c.UI.Error(fmt.Sprintf("unable to retrieve user events: %v", err))
 		return failure
 	}
 
-	n := 0
-	recurring := map[string]bool{}
-	for _, e := range events.Items {
-		if e.RecurringEventId != "" {
-			recurring[e.RecurringEventId] = true
-			continue // don't ingest recurring instances
+	c.UI.Output(fmt.Sprintf("Fetched %d events", len(events)))
+
+	if opts.dryRun {
+		return c.previewEvents(ctx, events)
+	}
+
+	n, removed, err := c.syncEvents(ctx, events)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return failure
+	}
+
+	c.UI.Output(fmt.Sprintf("Synced %d events, removed %d", n, removed))
+	return success
+}
+
+// previewEvents prints what syncing the google events would do,
+// i.e., "would CREATE: standup", without changing any fixtures.
+func (c *Cal2Command) previewEvents(ctx context.Context, events []*calendar.Event) int {
+	n, removed := 0, 0
+	for _, e := range events {
+		var m *data.Mutation
+		var err error
+
+		// events removed on google come back as cancelled
+		if e.Status == "cancelled" {
+			m, err = planRemoval(ctx, c.DBClient, c.UserID, e)
+		} else {
+			m, err = planEvent(ctx, c.DBClient, c.UserID, e)
 		}
-		c.UI.Output(fmt.Sprintf("Processing: %v", e.Summary))
-		_, err := ingestEvent(ctx, c.DBClient, c.UserID, e)
 		if err != nil {
 			c.UI.Error(err.Error())
 			return failure
 		}
-		n++
-	}
-	for id := range recurring {
-		e, err := srv.Events.Get("primary", id).Do()
-		if err != nil {
-			c.UI.Error(err.Error())
-			return failure
+
+		if m == nil {
+			continue
 		}
-		_, err = ingestEvent(ctx, c.DBClient, c.UserID, e)
-		if err != nil {
-			c.UI.Error(err.Error())
-			return failure
+
+		if m.Op == data.Mutation_DELETE {
+			removed++
+		} else {
+			n++
 		}
+		c.UI.Output(fmt.Sprintf("would %s: %s", m.Op, e.Summary))
 	}
+
+	masters, err := planMasterRemovals(ctx, c.DBClient, c.UserID, events)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return failure
+	}
+
+	for _, m := range masters {
+		removed++
+		c.UI.Output(fmt.Sprintf("would %s: %s", m.Op, m.Record.Fixture.Name))
+	}
+
+	c.UI.Output(fmt.Sprintf("Would sync %d events, remove %d", n, removed))
 	return success
 }
 
+// syncWorkers is the number of events synced concurrently
+const syncWorkers = 8
+
+// syncEvents ingests the google events, and removes the fixtures of those
+// which were cancelled, at most syncWorkers at a time, printing progress.
+// It then removes the fixtures of the recurring events, whose instances
+// were ingested. It returns the number of events ingested and fixtures
+// removed, and stops at the first error.
+func (c *Cal2Command) syncEvents(ctx context.Context, events []*calendar.Event) (n, removed int, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		done int
+	)
+
+	work := make(chan *calendar.Event)
+	for i := 0; i < syncWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range work {
+				var ok bool
+				var syncErr error
+
+				// events removed on google come back as cancelled
+				if e.Status == "cancelled" {
+					ok, syncErr = removeEvent(ctx, c.DBClient, c.UserID, e)
+				} else {
+					_, syncErr = ingestEvent(ctx, c.DBClient, c.UserID, e)
+				}
+
+				mu.Lock()
+				switch {
+				case syncErr != nil:
+					if err == nil {
+						err = syncErr
+						cancel()
+					}
+				case e.Status == "cancelled":
+					if ok {
+						removed++
+					}
+				default:
+					n++
+				}
+				done++
+				if syncErr == nil {
+					c.UI.Output(fmt.Sprintf("[%d/%d] Processed: %v", done, len(events), e.Summary))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+Feed:
+	for _, e := range events {
+		select {
+		case work <- e:
+		case <-ctx.Done():
+			break Feed
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if err == nil && ctx.Err() != nil && ctx.Err() != context.Canceled {
+		err = ctx.Err()
+	}
+
+	if err == nil {
+		var masters int
+		masters, err = removeMasters(ctx, c.DBClient, c.UserID, events)
+		removed += masters
+	}
+
+	return n, removed, err
+}
+
 func mainID(srv *calendar.Service) (string, error) {
 	cl, err := srv.CalendarList.List().Do()
 	if err != nil {
@@ -270,6 +838,10 @@ func mainID(srv *calendar.Service) (string, error) {
 
 // getClient uses a Context and Config to retrieve a Token
 // then generate a Client. It returns the generated Client.
+//
+// A cached token which has expired is refreshed, and the refreshed
+// token is cached. If it can't be refreshed, the user re-authorizes
+// on the web, as they do when there is no cached token.
 func getClient(ctx context.Context, config *oauth2.Config, u string) *http.Client {
 	cacheFile, err := tokenCacheFile(u)
 	if err != nil {
@@ -277,10 +849,27 @@ func getClient(ctx context.Context, config *oauth2.Config, u string) *http.Clien
 	}
 	tok, err := tokenFromFile(cacheFile)
 	if err != nil {
+		log.Printf("no cached token for %s, authorizing on the web", u)
 		tok = getTokenFromWeb(config)
 		saveToken(cacheFile, tok)
+		return config.Client(ctx, tok)
 	}
-	return config.Client(ctx, tok)
+
+	// the token source only refreshes the token if it has expired
+	fresh, err := config.TokenSource(ctx, tok).Token()
+	switch {
+	case err != nil:
+		log.Printf("cached token for %s could not be refreshed (%v), re-authorizing on the web", u, err)
+		fresh = getTokenFromWeb(config)
+		saveToken(cacheFile, fresh)
+	case fresh.AccessToken != tok.AccessToken:
+		log.Printf("cached token for %s had expired, refreshed it", u)
+		saveToken(cacheFile, fresh)
+	default:
+		log.Printf("using cached token for %s", u)
+	}
+
+	return config.Client(ctx, fresh)
 }
 
 // getTokenFromWeb uses Config to request a Token.
@@ -0,0 +1,380 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elos/data/builtin/mem"
+	"github.com/elos/x/data"
+	"github.com/elos/x/models"
+	"github.com/mitchellh/cli"
+	calendar "google.golang.org/api/calendar/v3"
+)
+
+// fixtureState constructs a prior state of a user with a single
+// fixture, named "standup", on Tuesday Jan 7 2020, 10-11AM
+func fixtureState() data.State {
+	return data.State{
+		models.Kind_USER: []*data.Record{
+			&data.Record{
+				Kind: models.Kind_USER,
+				User: &models.User{
+					Id: "1",
+				},
+			},
+		},
+		models.Kind_FIXTURE: []*data.Record{
+			&data.Record{
+				Kind: models.Kind_FIXTURE,
+				Fixture: &models.Fixture{
+					Id:        "2",
+					OwnerId:   "1",
+					Name:      "standup",
+					StartTime: models.TimestampFrom(time.Date(2020, time.January, 7, 10, 0, 0, 0, time.Local)),
+					EndTime:   models.TimestampFrom(time.Date(2020, time.January, 7, 11, 0, 0, 0, time.Local)),
+				},
+			},
+		},
+	}
+}
+
+func TestCal2(t *testing.T) {
+	cases := map[string]struct {
+		args             []string
+		code             int
+		in, err, out     []byte
+		prior, posterior data.State
+	}{
+		// elos cal2 week {{{
+		"elos cal2 week -from 2020-01-06": {
+			args: []string{"week", "-from", "2020-01-06"},
+			out: []byte(strings.Join([]string{
+				"Monday Jan 6",
+				" — nothing",
+				"Tuesday Jan 7",
+				" - standup [10:00AM-11:00AM]",
+				"Wednesday Jan 8",
+				" — nothing",
+				"Thursday Jan 9",
+				" — nothing",
+				"Friday Jan 10",
+				" — nothing",
+				"Saturday Jan 11",
+				" — nothing",
+				"Sunday Jan 12",
+				" — nothing",
+			}, "\n") + "\n"),
+			prior: fixtureState(),
+		},
+		// }}}
+
+		// elos cal2 day {{{
+		"elos cal2 day 2020-01-07": {
+			args:  []string{"day", "2020-01-07"},
+			out:   []byte(" - standup [10:00AM-11:00AM]\n"),
+			prior: fixtureState(),
+		},
+		// }}}
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			db := mem.NewDB()
+			dbc, conn, err := data.DBBothLocal(ctx, db)
+			if err != nil {
+				t.Fatalf("data.DBBothLocal error: %v", err)
+			}
+			defer conn.Close()
+			if err := data.Seed(context.Background(), dbc, c.prior); err != nil {
+				t.Fatalf("data.Seed error: %v", err)
+			}
+
+			ui := &cli.MockUi{
+				InputReader: bytes.NewBuffer(c.in),
+			}
+			cmd := &Cal2Command{
+				UI:       ui,
+				UserID:   c.prior[models.Kind_USER][0].User.Id,
+				DBClient: dbc,
+			}
+
+			if got, want := cmd.Run(c.args), c.code; got != want {
+				t.Log(ui.ErrorWriter.String())
+				t.Fatalf("cmd.Run(%v): got %d, want %d", c.args, got, want)
+			}
+
+			if got, want := ui.ErrorWriter.String(), string(c.err); got != want {
+				t.Fatalf("ui.ErrorWriter.String(): got %q, want %q", got, want)
+			}
+
+			if got, want := ui.OutputWriter.String(), string(c.out); got != want {
+				t.Fatalf("ui.OutputWriter.String(): got %q, want %q", got, want)
+			}
+
+			finalState := c.prior
+			if c.posterior != nil {
+				finalState = c.posterior
+			}
+
+			if got, want := data.CompareState(context.Background(), dbc, finalState), error(nil); got != want {
+				t.Fatalf("data.CompareState: got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// TestCal2New tests that an event created with `elos cal2 new`
+// is listed for its day
+func TestCal2New(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db := mem.NewDB()
+	dbc, conn, err := data.DBBothLocal(ctx, db)
+	if err != nil {
+		t.Fatalf("data.DBBothLocal error: %v", err)
+	}
+	defer conn.Close()
+
+	prior := fixtureState()
+	delete(prior, models.Kind_FIXTURE)
+	if err := data.Seed(context.Background(), dbc, prior); err != nil {
+		t.Fatalf("data.Seed error: %v", err)
+	}
+
+	ui := &cli.MockUi{
+		InputReader: bytes.NewBufferString(strings.Join([]string{
+			"review", // name
+			"2020",   // year
+			"1",      // month
+			"7",      // day
+			"14",     // hour
+			"30",     // minute
+			"15",     // end hour
+			"0",      // end minute
+		}, "\n") + "\n"),
+	}
+	cmd := &Cal2Command{
+		UI:       ui,
+		UserID:   "1",
+		DBClient: dbc,
+	}
+
+	if got, want := cmd.Run([]string{"new"}), success; got != want {
+		t.Log(ui.ErrorWriter.String())
+		t.Fatalf("cmd.Run(new): got %d, want %d", got, want)
+	}
+
+	if got, want := ui.ErrorWriter.String(), ""; got != want {
+		t.Fatalf("ui.ErrorWriter.String(): got %q, want %q", got, want)
+	}
+
+	ui.OutputWriter.Reset()
+
+	if got, want := cmd.Run([]string{"day", "2020-01-07"}), success; got != want {
+		t.Log(ui.ErrorWriter.String())
+		t.Fatalf("cmd.Run(day): got %d, want %d", got, want)
+	}
+
+	if got, want := ui.OutputWriter.String(), " - review [2:30PM-3:00PM]\n"; got != want {
+		t.Fatalf("ui.OutputWriter.String(): got %q, want %q", got, want)
+	}
+}
+
+// TestCal2DayLocation tests that the days listed by `elos cal2 day`
+// begin and end in the configured location, rather than local time
+func TestCal2DayLocation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db := mem.NewDB()
+	dbc, conn, err := data.DBBothLocal(ctx, db)
+	if err != nil {
+		t.Fatalf("data.DBBothLocal error: %v", err)
+	}
+	defer conn.Close()
+
+	// twelve hours ahead of local time, so early on Jan 7
+	// there is still Jan 6 in local time
+	_, offset := time.Date(2020, time.January, 7, 0, 0, 0, 0, time.Local).Zone()
+	loc := time.FixedZone("ahead", offset+12*60*60)
+
+	prior := fixtureState()
+	f := prior[models.Kind_FIXTURE][0].Fixture
+	f.StartTime = models.TimestampFrom(time.Date(2020, time.January, 7, 1, 0, 0, 0, loc))
+	f.EndTime = models.TimestampFrom(time.Date(2020, time.January, 7, 2, 0, 0, 0, loc))
+	if err := data.Seed(context.Background(), dbc, prior); err != nil {
+		t.Fatalf("data.Seed error: %v", err)
+	}
+
+	ui := new(cli.MockUi)
+	cmd := &Cal2Command{
+		UI:       ui,
+		UserID:   "1",
+		DBClient: dbc,
+		Location: loc,
+	}
+
+	if got, want := cmd.Run([]string{"day", "2020-01-07"}), success; got != want {
+		t.Log(ui.ErrorWriter.String())
+		t.Fatalf("cmd.Run(day): got %d, want %d", got, want)
+	}
+
+	if got, want := ui.OutputWriter.String(), " - standup [1:00AM-2:00AM]\n"; got != want {
+		t.Fatalf("ui.OutputWriter.String(): got %q, want %q", got, want)
+	}
+}
+
+func TestParseGoogleOptionsTimeout(t *testing.T) {
+	now := time.Now()
+
+	opts, err := parseGoogleOptions(nil, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := opts.timeout, 10*time.Minute; got != want {
+		t.Fatalf("default timeout: got %s, want %s", got, want)
+	}
+
+	if opts, err = parseGoogleOptions([]string{"-timeout", "90s"}, now); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := opts.timeout, 90*time.Second; got != want {
+		t.Fatalf("timeout: got %s, want %s", got, want)
+	}
+
+	for _, args := range [][]string{{"-timeout"}, {"-timeout", "soon"}, {"-timeout", "-1m"}} {
+		if _, err := parseGoogleOptions(args, now); err == nil {
+			t.Fatalf("parseGoogleOptions(%v): expected an error", args)
+		}
+	}
+}
+
+// TestCal2PreviewEvents tests that a dry run of the google sync reports
+// what it would do, without changing any fixtures
+func TestCal2PreviewEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db := mem.NewDB()
+	dbc, conn, err := data.DBBothLocal(ctx, db)
+	if err != nil {
+		t.Fatalf("data.DBBothLocal error: %v", err)
+	}
+	defer conn.Close()
+
+	prior := fixtureState()
+	prior[models.Kind_FIXTURE][0].Fixture.Labels = map[string]string{googleEventLabel: "g1"}
+	if err := data.Seed(context.Background(), dbc, prior); err != nil {
+		t.Fatalf("data.Seed error: %v", err)
+	}
+
+	event := func(id, summary, status string) *calendar.Event {
+		return &calendar.Event{
+			Id:      id,
+			Summary: summary,
+			Status:  status,
+			Start:   &calendar.EventDateTime{DateTime: "2020-01-07T10:00:00Z"},
+			End:     &calendar.EventDateTime{DateTime: "2020-01-07T11:00:00Z"},
+		}
+	}
+
+	ui := new(cli.MockUi)
+	cmd := &Cal2Command{
+		UI:       ui,
+		UserID:   "1",
+		DBClient: dbc,
+	}
+
+	events := []*calendar.Event{
+		event("g1", "standup", "confirmed"),
+		event("g2", "review", "confirmed"),
+		event("g3", "cancelled meeting", "cancelled"),
+	}
+	if got, want := cmd.previewEvents(ctx, events), success; got != want {
+		t.Log(ui.ErrorWriter.String())
+		t.Fatalf("cmd.previewEvents: got %d, want %d", got, want)
+	}
+
+	want := "would UPDATE: standup\nwould CREATE: review\nWould sync 2 events, remove 0\n"
+	if got := ui.OutputWriter.String(); got != want {
+		t.Fatalf("ui.OutputWriter.String(): got %q, want %q", got, want)
+	}
+
+	if got, want := data.CompareState(context.Background(), dbc, prior), error(nil); got != want {
+		t.Fatalf("data.CompareState: got %v, want %v", got, want)
+	}
+}
+
+// TestCal2SyncRemovesMaster tests that syncing the instances of a
+// recurring event removes the fixture which was ingested from the
+// recurring event itself, before instances were ingested
+func TestCal2SyncRemovesMaster(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db := mem.NewDB()
+	dbc, conn, err := data.DBBothLocal(ctx, db)
+	if err != nil {
+		t.Fatalf("data.DBBothLocal error: %v", err)
+	}
+	defer conn.Close()
+
+	// "standup" was ingested from the recurring event m1
+	prior := fixtureState()
+	prior[models.Kind_FIXTURE][0].Fixture.Labels = map[string]string{googleEventLabel: "m1"}
+	if err := data.Seed(context.Background(), dbc, prior); err != nil {
+		t.Fatalf("data.Seed error: %v", err)
+	}
+
+	instance := func(id, day string) *calendar.Event {
+		return &calendar.Event{
+			Id:               id,
+			RecurringEventId: "m1",
+			Summary:          "standup",
+			Status:           "confirmed",
+			Start:            &calendar.EventDateTime{DateTime: day + "T10:00:00Z"},
+			End:              &calendar.EventDateTime{DateTime: day + "T11:00:00Z"},
+		}
+	}
+
+	ui := new(cli.MockUi)
+	cmd := &Cal2Command{
+		UI:       ui,
+		UserID:   "1",
+		DBClient: dbc,
+	}
+
+	events := []*calendar.Event{
+		instance("m1_20200107", "2020-01-07"),
+		instance("m1_20200114", "2020-01-14"),
+	}
+
+	n, removed, err := cmd.syncEvents(ctx, events)
+	if err != nil {
+		t.Fatalf("cmd.syncEvents error: %v", err)
+	}
+	if n != 2 || removed != 1 {
+		t.Fatalf("cmd.syncEvents: got %d synced, %d removed, want 2 and 1", n, removed)
+	}
+
+	if f, err := findGoogleFixture(ctx, dbc, "1", "m1"); err != nil {
+		t.Fatal(err)
+	} else if f != nil {
+		t.Fatal("Expected the fixture of the recurring event to be removed")
+	}
+
+	for _, e := range events {
+		if f, err := findGoogleFixture(ctx, dbc, "1", e.Id); err != nil {
+			t.Fatal(err)
+		} else if f == nil {
+			t.Fatalf("Expected a fixture for the instance %s", e.Id)
+		}
+	}
+}
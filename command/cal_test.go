@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"strings"
 	"testing"
 	"time"
 
@@ -118,6 +119,208 @@ func TestCalInadequateInitialization(t *testing.T) {
 
 // --- }}}
 
+// --- Integration {{{
+
+// --- `elos cal today` {{{
+
+func newTestFixture(t *testing.T, db olddata.DB, u *oldmodels.User, name string, startHour, endHour int) *oldmodels.Fixture {
+	f := oldmodels.NewFixture()
+	f.SetID(db.NewID())
+	f.OwnerId = u.ID().String()
+	f.Name = name
+	f.StartTime = time.Date(0, 0, 0, startHour, 0, 0, 0, time.Local)
+	f.EndTime = time.Date(0, 0, 0, endHour, 0, 0, 0, time.Local)
+	if err := db.Save(f); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func newTestSchedule(t *testing.T, db olddata.DB, u *oldmodels.User, fixtures ...*oldmodels.Fixture) *oldmodels.Schedule {
+	s := oldmodels.NewSchedule()
+	s.SetID(db.NewID())
+	s.OwnerId = u.ID().String()
+	for _, f := range fixtures {
+		s.IncludeFixture(f)
+	}
+	if err := db.Save(s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestCalToday(t *testing.T) {
+	ui, db, user, c := newMockCalCommand(t)
+
+	cal := newTestCalendar(t, db, user)
+	cal.SetBaseSchedule(newTestSchedule(t, db, user,
+		newTestFixture(t, db, user, "standup", 9, 10),
+		newTestFixture(t, db, user, "lunch", 12, 13),
+	))
+	cal.WeekdaySchedules = map[string]string{
+		weekdayKey(int(time.Now().Weekday())): newTestSchedule(t, db, user,
+			newTestFixture(t, db, user, "gym", 8, 10),
+		).Id,
+	}
+	if err := db.Save(cal); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("running: `elos cal today`")
+	code := c.Run([]string{"today"})
+	t.Log("command `today` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// the weekday fixture overrides the overlapping base fixture
+	if !strings.Contains(output, "gym [08:00 - 10:00] (weekday)") {
+		t.Fatalf("Output should have contained the weekday fixture")
+	}
+
+	if strings.Contains(output, "standup") {
+		t.Fatalf("Output should not have contained the overridden base fixture")
+	}
+
+	if !strings.Contains(output, "lunch [12:00 - 13:00] (base)") {
+		t.Fatalf("Output should have contained the non-conflicting base fixture")
+	}
+}
+
+// --- }}}
+
+// --- `elos cal scheduling weekday` {{{
+
+func TestCalSchedulingWeekday(t *testing.T) {
+	ui, db, user, c := newMockCalCommand(t)
+
+	cal := newTestCalendar(t, db, user)
+	if err := db.Save(cal); err != nil {
+		t.Fatal(err)
+	}
+
+	input := strings.Join([]string{
+		"1", // Monday
+		"n", // don't add a fixture
+	}, "\n")
+
+	ui.InputReader = bytes.NewBufferString(input)
+
+	t.Log("running: `elos cal scheduling weekday`")
+	code := c.Run([]string{"scheduling", "weekday"})
+	t.Log("command `scheduling weekday` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// reload the calendar
+	if err := db.PopulateByID(cal); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduleID, ok := cal.WeekdaySchedules["1"]
+	if !ok {
+		t.Fatalf("Expected a schedule under the key \"1\", got: %v", cal.WeekdaySchedules)
+	}
+
+	schedule := oldmodels.NewSchedule()
+	schedule.Id = scheduleID
+	if err := db.PopulateByID(schedule); err != nil {
+		t.Fatalf("Error retrieving the weekday schedule: %s", err)
+	}
+}
+
+// --- }}}
+
+// --- `elos cal scheduling yearday` {{{
+
+func TestCalSchedulingYearday(t *testing.T) {
+	ui, db, user, c := newMockCalCommand(t)
+
+	cal := newTestCalendar(t, db, user)
+	if err := db.Save(cal); err != nil {
+		t.Fatal(err)
+	}
+
+	input := strings.Join([]string{
+		"2",  // February
+		"30", // not a day of February, so asked again
+		"2",  // February
+		"29", // the leap day
+		"n",  // don't add a fixture
+	}, "\n")
+
+	ui.InputReader = bytes.NewBufferString(input)
+
+	t.Log("running: `elos cal scheduling yearday`")
+	code := c.Run([]string{"scheduling", "yearday"})
+	t.Log("command `scheduling yearday` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// verify some of the output
+	if !strings.Contains(output, "February 29 Schedule Fixtures") {
+		t.Fatalf("Output should have contained the day of the schedule")
+	}
+
+	// reload the calendar
+	if err := db.PopulateByID(cal); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduleID, ok := cal.YeardaySchedules["02-29"]
+	if !ok {
+		t.Fatalf("Expected a schedule under the key \"02-29\", got: %v", cal.YeardaySchedules)
+	}
+
+	schedule := oldmodels.NewSchedule()
+	schedule.Id = scheduleID
+	if err := db.PopulateByID(schedule); err != nil {
+		t.Fatalf("Error retrieving the yearday schedule: %s", err)
+	}
+}
+
+// --- }}}
+
+// --- }}}
+
 // --- }}}
 
 func TestCal(t *testing.T) {
@@ -226,3 +429,24 @@ func TestCal(t *testing.T) {
 		})
 	}
 }
+
+// TestFixtureInProgress tests that a recurring fixture, created on an
+// earlier date, is in progress during its time of day
+func TestFixtureInProgress(t *testing.T) {
+	f := oldmodels.NewFixture()
+	f.StartTime = time.Date(2015, time.March, 2, 9, 0, 0, 0, time.Local)
+	f.EndTime = time.Date(2015, time.March, 2, 10, 30, 0, 0, time.Local)
+
+	cases := map[time.Time]bool{
+		time.Date(2020, time.January, 7, 8, 59, 0, 0, time.Local):  false,
+		time.Date(2020, time.January, 7, 9, 0, 0, 0, time.Local):   true,
+		time.Date(2020, time.January, 7, 10, 15, 0, 0, time.Local): true,
+		time.Date(2020, time.January, 7, 10, 30, 0, 0, time.Local): false,
+	}
+
+	for now, want := range cases {
+		if got := fixtureInProgress(f, now); got != want {
+			t.Fatalf("fixtureInProgress at %s: got %t, want %t", now.Format("15:04"), got, want)
+		}
+	}
+}
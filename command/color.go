@@ -0,0 +1,59 @@
+package command
+
+import (
+	"os"
+)
+
+// ANSI escape codes for the colors output may be in
+const (
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorDim   = "\x1b[2m"
+	colorReset = "\x1b[0m"
+)
+
+// Color indicates whether output is colored. It is off unless turned
+// on, which the elos command does by way of ParseColor, so that output
+// which isn't for a terminal, i.e., a text session, is never colored.
+var Color bool
+
+// ParseColor removes the global --no-color flag from the arguments,
+// and determines whether output to out should be colored. It should
+// not be if the flag is given, the NO_COLOR environment variable is
+// set, or out isn't a terminal.
+func ParseColor(args []string, out *os.File) ([]string, bool) {
+	color := true
+
+	rest := make([]string, 0, len(args))
+	for _, a := range args {
+		if a == "-no-color" || a == "--no-color" {
+			color = false
+			continue
+		}
+
+		rest = append(rest, a)
+	}
+
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		color = false
+	}
+
+	if out == nil {
+		return rest, false
+	}
+
+	if fi, err := out.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		color = false
+	}
+
+	return rest, color
+}
+
+// colorize wraps the string in the color, if output is colored
+func colorize(color, s string) string {
+	if !Color || s == "" {
+		return s
+	}
+
+	return color + s + colorReset
+}
@@ -0,0 +1,93 @@
+package command
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elos/models/habit"
+	"github.com/elos/x/models"
+)
+
+func TestParseColor(t *testing.T) {
+	args, color := ParseColor([]string{"todo", "--no-color", "list"}, os.Stdout)
+
+	if want := []string{"todo", "list"}; !reflect.DeepEqual(args, want) {
+		t.Fatalf("Expected the arguments %v, got %v", want, args)
+	}
+
+	if color {
+		t.Fatal("Expected no color with --no-color")
+	}
+
+	// without a terminal, there is no color
+	if _, color := ParseColor([]string{"todo", "list"}, nil); color {
+		t.Fatal("Expected no color without a terminal")
+	}
+}
+
+// todoListOutput is the output of `elos todo list`, listing
+// a tagged task whose deadline has passed
+func todoListOutput(t *testing.T) string {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	tsk := newTestTask(t, db, user)
+	tsk.Name = "overdue"
+	tsk.Tags = []string{"URGENT"}
+	tsk.DeadlineAt = models.TimestampFrom(time.Now().Add(-time.Hour))
+	if err := db.Save(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := c.Run([]string{"list"}); code != success {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	return ui.OutputWriter.String()
+}
+
+// habitTodayOutput is the output of `elos habit today`,
+// listing a habit which has been checked in today
+func habitTodayOutput(t *testing.T) string {
+	ui, db, user, c := newMockHabitCommand(t)
+
+	h := newTestHabit(t, db, user, "first")
+	if _, err := habit.CheckinFor(db, h, "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := c.Run([]string{"today"}); code != success {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	return ui.OutputWriter.String()
+}
+
+// TestNoColor tests that no escape codes are output with
+// --no-color, where they would be if output were colored
+func TestNoColor(t *testing.T) {
+	defer func(c bool) { Color = c }(Color)
+
+	// as if output were to a terminal
+	Color = true
+	for name, output := range map[string]func(*testing.T) string{
+		"todo list":   todoListOutput,
+		"habit today": habitTodayOutput,
+	} {
+		if out := output(t); !strings.Contains(out, "\x1b[") {
+			t.Fatalf("Expected `elos %s` to be colored, got: %q", name, out)
+		}
+	}
+
+	_, Color = ParseColor([]string{"todo", "list", "--no-color"}, os.Stdout)
+	for name, output := range map[string]func(*testing.T) string{
+		"todo list":   todoListOutput,
+		"habit today": habitTodayOutput,
+	} {
+		if out := output(t); strings.Contains(out, "\x1b[") {
+			t.Fatalf("Expected no escape codes in `elos %s` with --no-color, got: %q", name, out)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// subcommands are the subcommands of each command, which are
+// offered as completions after the command's name.
+//
+// Keep these in sync with the Run method of each command.
+var subcommands = map[string][]string{
+	"auth":    {"logout"},
+	"cal":     {"now", "next", "today", "scheduling"},
+	"cal2":    {"day", "week", "google", "new", "next", "now"},
+	"conf":    {"all", "db", "edit", "host", "userid"},
+	"event":   {"new"},
+	"habit":   {"checkin", "delete", "history", "list", "new", "report", "skip", "today"},
+	"note":    {"list", "new", "search"},
+	"people":  {"delete", "edit", "list", "merge", "new", "note", "search", "show", "stream"},
+	"records": {"changes", "count", "delete", "get", "kinds", "query"},
+	"tag":     {"delete", "edit", "list", "merge", "new"},
+	"todo": {
+		"abandon", "clean", "complete", "current", "deadline", "delete", "edit", "estimate", "fix", "goal", "goals",
+		"import", "list", "move", "new", "prereqs", "ready", "review", "salience", "snooze", "start", "stop",
+		"suggest", "tag", "today", "waiting", "weight",
+	},
+	"x": {"review", "tasktime"},
+}
+
+// CompletionCommand contains the state necessary to implement the
+// 'elos completion' command.
+//
+// It implements the cli.Command interface
+type CompletionCommand struct {
+	// UI is used to communicate (for IO) with the user
+	// It must be non-nil
+	UI cli.Ui
+
+	// Commands are the top-level commands to complete
+	Commands map[string]cli.CommandFactory
+}
+
+// Synopsis is a one-line, short summary of the 'completion' command.
+// It is guaranteed to be at most 50 characters.
+func (c *CompletionCommand) Synopsis() string {
+	return "Generate shell completion scripts"
+}
+
+// Help is the long-form help text for the 'completion' command.
+func (c *CompletionCommand) Help() string {
+	helpText := `
+Usage:
+	elos completion <bash|zsh>
+
+	Prints a completion script for the shell, to enable it:
+		source <(elos completion bash)
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Run runs the 'completion' command with the given command-line arguments.
+// It returns an exit status when it finishes. 0 indicates a success,
+// any other integer indicates a failure.
+func (c *CompletionCommand) Run(args []string) int {
+	if c.UI == nil {
+		return failure
+	}
+
+	if len(args) != 1 {
+		c.UI.Output(c.Help())
+		return failure
+	}
+
+	switch args[0] {
+	case "bash":
+		c.UI.Output(c.bash())
+	case "zsh":
+		c.UI.Output(c.zsh())
+	default:
+		c.UI.Error(fmt.Sprintf("(elos completion) Error: unsupported shell %q", args[0]))
+		return failure
+	}
+
+	return success
+}
+
+// names returns the sorted names of the top-level commands
+func (c *CompletionCommand) names() []string {
+	names := make([]string, 0, len(c.Commands))
+	for name := range c.Commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// bash constructs the bash completion script
+func (c *CompletionCommand) bash() string {
+	b := new(bytes.Buffer)
+	names := c.names()
+
+	fmt.Fprintln(b, "_elos() {")
+	fmt.Fprintln(b, "\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\"")
+	fmt.Fprintln(b, "\tif [ \"$COMP_CWORD\" -eq 1 ]; then")
+	fmt.Fprintf(b, "\t\tCOMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") )\n", strings.Join(names, " "))
+	fmt.Fprintln(b, "\telif [ \"$COMP_CWORD\" -eq 2 ]; then")
+	fmt.Fprintln(b, "\t\tcase \"${COMP_WORDS[1]}\" in")
+	for _, name := range names {
+		if subs, ok := subcommands[name]; ok {
+			fmt.Fprintf(b, "\t\t%s) COMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") ) ;;\n", name, strings.Join(subs, " "))
+		}
+	}
+	fmt.Fprintln(b, "\t\tesac")
+	fmt.Fprintln(b, "\tfi")
+	fmt.Fprintln(b, "}")
+	fmt.Fprint(b, "complete -F _elos elos")
+
+	return b.String()
+}
+
+// zsh constructs the zsh completion script
+func (c *CompletionCommand) zsh() string {
+	b := new(bytes.Buffer)
+	names := c.names()
+
+	fmt.Fprintln(b, "#compdef elos")
+	fmt.Fprintln(b, "_elos() {")
+	fmt.Fprintln(b, "\tif (( CURRENT == 2 )); then")
+	fmt.Fprintf(b, "\t\tcompadd %s\n", strings.Join(names, " "))
+	fmt.Fprintln(b, "\telif (( CURRENT == 3 )); then")
+	fmt.Fprintln(b, "\t\tcase \"$words[2]\" in")
+	for _, name := range names {
+		if subs, ok := subcommands[name]; ok {
+			fmt.Fprintf(b, "\t\t%s) compadd %s ;;\n", name, strings.Join(subs, " "))
+		}
+	}
+	fmt.Fprintln(b, "\t\tesac")
+	fmt.Fprintln(b, "\tfi")
+	fmt.Fprintln(b, "}")
+	fmt.Fprint(b, "compdef _elos elos")
+
+	return b.String()
+}
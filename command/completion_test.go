@@ -0,0 +1,61 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func newMockCompletionCommand() (*cli.MockUi, *CompletionCommand) {
+	ui := new(cli.MockUi)
+	factory := func() (cli.Command, error) { return nil, nil }
+
+	return ui, &CompletionCommand{
+		UI: ui,
+		Commands: map[string]cli.CommandFactory{
+			"todo":  factory,
+			"setup": factory,
+		},
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh"} {
+		t.Run(shell, func(t *testing.T) {
+			ui, c := newMockCompletionCommand()
+
+			if code := c.Run([]string{shell}); code != success {
+				t.Fatalf("c.Run: got %d, want %d", code, success)
+			}
+
+			output := ui.OutputWriter.String()
+			t.Logf("Output:\n%s", output)
+
+			if !strings.Contains(output, "setup todo") {
+				t.Fatal("Expected the top-level commands to be completed")
+			}
+
+			if !strings.Contains(output, "todo) ") || !strings.Contains(output, "complete current") {
+				t.Fatal("Expected the todo subcommands to be completed")
+			}
+
+			// only registered commands are completed
+			if strings.Contains(output, "habit") {
+				t.Fatal("Expected only the registered commands to be completed")
+			}
+		})
+	}
+}
+
+func TestCompletionUnknownShell(t *testing.T) {
+	ui, c := newMockCompletionCommand()
+
+	if code := c.Run([]string{"fish"}); code != failure {
+		t.Fatalf("c.Run: got %d, want %d", code, failure)
+	}
+
+	if got, want := ui.ErrorWriter.String(), "(elos completion) Error: unsupported shell \"fish\"\n"; got != want {
+		t.Fatalf("ui.ErrorWriter.String(): got %q, want %q", got, want)
+	}
+}
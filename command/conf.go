@@ -2,6 +2,8 @@ package command
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/mitchellh/cli"
@@ -23,10 +25,14 @@ Usage: elos conf {[field] | edit} [edit]
 	When no field provided elos conf prints the entire current configuration.
 
 	Note: You may not edit or view your user credentials here, you must use
-	'elos auth' to do so.
+	'elos auth' to do so. You may view the id of the user they belong to
+	with 'elos conf userid'.
 
 Examples:
 	elos conf				Prints all configuration
+	elos conf all			Prints every field, except credentials
+	elos conf userid		Prints the id of the current user
+	elos conf host list		Prints the hosts you have used
 	elos conf edit			Edits all configuration
 	elos conf <field>		Prints field's configuration
 	elos conf <field> edit	Edits fields configuration
@@ -52,6 +58,11 @@ func (c *ConfCommand) Run(args []string) int {
 			return c.editHost()
 		}
 
+		if len(args) == 2 && args[1] == "list" {
+			c.printHosts()
+			return 0
+		}
+
 		c.Ui.Output(fmt.Sprintf("Your current host is %s", c.Config.Host))
 		break
 	case "db":
@@ -61,6 +72,10 @@ func (c *ConfCommand) Run(args []string) int {
 
 		c.Ui.Output(fmt.Sprintf("Your current db is %s:", c.Config.DB))
 		break
+	case "userid":
+		c.Ui.Output(fmt.Sprintf("Your current user id is %s", c.Config.UserID))
+	case "all":
+		c.printAll()
 	case "help":
 		fallthrough
 	case "-help":
@@ -78,6 +93,43 @@ func (c *ConfCommand) Run(args []string) int {
 	return 0
 }
 
+// printAll prints every field of the configuration, except for
+// the credentials, which must never be printed.
+func (c *ConfCommand) printAll() {
+	c.Ui.Output("Your current configuration:")
+	c.Ui.Output(fmt.Sprintf("Path: %s", c.Config.Path))
+	c.Ui.Output(fmt.Sprintf("Host: %s", c.Config.Host))
+	c.Ui.Output(fmt.Sprintf("Hosts: %s", strings.Join(c.Config.Hosts, ", ")))
+	c.Ui.Output(fmt.Sprintf("DB: %s", c.Config.DB))
+	c.Ui.Output(fmt.Sprintf("DirectDB: %t", c.Config.DirectDB))
+	c.Ui.Output(fmt.Sprintf("UserID: %s", c.Config.UserID))
+	c.Ui.Output(fmt.Sprintf("OwnerID: %s", c.Config.Credential.OwnerID))
+	c.Ui.Output(fmt.Sprintf("Timezone: %s", c.Config.Timezone))
+
+	// print the timeout in effect, an invalid timeout means none
+	switch timeout, err := c.Config.HTTPTimeout(); {
+	case err != nil:
+		c.Ui.Output(fmt.Sprintf("Timeout: none (invalid timeout %q)", c.Config.Timeout))
+	case timeout == 0:
+		c.Ui.Output("Timeout: none")
+	default:
+		c.Ui.Output(fmt.Sprintf("Timeout: %s", timeout))
+	}
+
+	c.Ui.Output(fmt.Sprintf("Retries: %d", c.Config.Retries))
+
+	names := make([]string, 0, len(c.Config.TagWeights))
+	for name := range c.Config.TagWeights {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	c.Ui.Output("TagWeights:")
+	for _, name := range names {
+		c.Ui.Output(fmt.Sprintf("\t%s: %g", name, c.Config.TagWeights[name]))
+	}
+}
+
 func (c *ConfCommand) editConf(args []string) int {
 	if o := c.editHost(); o != 0 {
 		return o
@@ -92,7 +144,7 @@ func (c *ConfCommand) editConf(args []string) int {
 
 func (c *ConfCommand) editHost() int {
 	c.Ui.Output(fmt.Sprintf("Your current host is %s", c.Config.Host))
-	host, err := c.Ui.Ask("What would you like your new host to be?")
+	host, err := promptHost(c.Ui, c.Config.Hosts, "What would you like your new host to be?")
 
 	if err != nil {
 		c.Ui.Error(err.Error())
@@ -101,7 +153,7 @@ func (c *ConfCommand) editHost() int {
 
 	// if host valid, currently only checks non empty
 	if host != "" {
-		c.Config.Host = host
+		c.Config.UseHost(host)
 	} else {
 		c.Ui.Warn("You entered an empty host name")
 	}
@@ -116,6 +168,42 @@ func (c *ConfCommand) editHost() int {
 	return 0
 }
 
+// printHosts prints a numbered list of the hosts which have been used
+func (c *ConfCommand) printHosts() {
+	if len(c.Config.Hosts) == 0 {
+		c.Ui.Output("You have not used any hosts")
+		return
+	}
+
+	for i, h := range c.Config.Hosts {
+		c.Ui.Output(fmt.Sprintf("%d) %s", i, h))
+	}
+}
+
+// promptHost asks for a host, listing the previously used hosts
+// so that one can be selected by number rather than retyped.
+func promptHost(ui cli.Ui, hosts []string, text string) (string, error) {
+	if len(hosts) == 0 {
+		return ui.Ask(text)
+	}
+
+	ui.Output("Previously used hosts:")
+	for i, h := range hosts {
+		ui.Output(fmt.Sprintf("%d) %s", i, h))
+	}
+
+	host, err := ui.Ask(text + " [host, or # of a previous host]")
+	if err != nil {
+		return "", err
+	}
+
+	if i, err := strconv.Atoi(host); err == nil && i >= 0 && i < len(hosts) {
+		return hosts[i], nil
+	}
+
+	return host, nil
+}
+
 func (c *ConfCommand) editDB() int {
 	c.Ui.Output(fmt.Sprintf("Your current db is %s:", c.Config.DB))
 	db, err := c.Ui.Ask("What would you like your new db to be?")
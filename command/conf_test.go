@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/elos/elos/command"
@@ -48,3 +49,140 @@ func TestHostChange(t *testing.T) {
 
 	os.Remove(writtenConf.Path)
 }
+
+func TestHostHistory(t *testing.T) {
+	f, err := ioutil.TempFile("", "configtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	conf := &command.Config{
+		Path: f.Name(),
+	}
+
+	for _, host := range []string{"localhost:8000", "elos.pw"} {
+		ui := new(cli.MockUi)
+		ui.InputReader = bytes.NewBufferString(host + "\n")
+
+		c := &command.ConfCommand{
+			Ui:     ui,
+			Config: conf,
+		}
+
+		if code := c.Run([]string{"host", "edit"}); code != 0 {
+			t.Fatalf("Expected successful exit code, got %d", code)
+		}
+	}
+
+	writtenConf, err := command.ParseConfigFile(conf.Path)
+	if err != nil {
+		t.Fatalf("ParseConfigFile: %s", err)
+	}
+
+	if got, want := strings.Join(writtenConf.Hosts, ","), "localhost:8000,elos.pw"; got != want {
+		t.Fatalf("Hosts: got %q, want %q", got, want)
+	}
+
+	// now switch back by number
+	ui := new(cli.MockUi)
+	ui.InputReader = bytes.NewBufferString("0\n")
+	c := &command.ConfCommand{
+		Ui:     ui,
+		Config: writtenConf,
+	}
+
+	if code := c.Run([]string{"host", "edit"}); code != 0 {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	if got, want := writtenConf.Host, "localhost:8000"; got != want {
+		t.Fatalf("Host: got %q, want %q", got, want)
+	}
+
+	if got, want := strings.Join(writtenConf.Hosts, ","), "elos.pw,localhost:8000"; got != want {
+		t.Fatalf("Hosts: got %q, want %q", got, want)
+	}
+}
+
+func TestConfUserID(t *testing.T) {
+	ui := new(cli.MockUi)
+	conf := &command.Config{
+		UserID:            "1",
+		PrivateCredential: "private",
+	}
+
+	c := &command.ConfCommand{
+		Ui:     ui,
+		Config: conf,
+	}
+
+	if code := c.Run([]string{"userid"}); code != 0 {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	output := ui.OutputWriter.String()
+	if got, want := output, "Your current user id is 1\n"; got != want {
+		t.Fatalf("output: got %q, want %q", got, want)
+	}
+}
+
+func TestConfAll(t *testing.T) {
+	ui := new(cli.MockUi)
+	conf := &command.Config{
+		Host:              "elos.pw",
+		Hosts:             []string{"localhost", "elos.pw"},
+		UserID:            "1",
+		Timeout:           "10s",
+		Retries:           3,
+		TagWeights:        map[string]float64{"URGENT": 2, "LATER": 0.5},
+		PublicCredential:  "public",
+		PrivateCredential: "private",
+	}
+	conf.Credential.Private = "grpc-private"
+
+	c := &command.ConfCommand{
+		Ui:     ui,
+		Config: conf,
+	}
+
+	if code := c.Run([]string{"all"}); code != 0 {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	output := ui.OutputWriter.String()
+	for _, want := range []string{"Host: elos.pw", "Hosts: localhost, elos.pw", "UserID: 1", "Timeout: 10s", "Retries: 3"} {
+		if !strings.Contains(output, want) {
+			t.Fatalf("Output should contain %q, got: %s", want, output)
+		}
+	}
+
+	if later, urgent := strings.Index(output, "LATER: 0.5"), strings.Index(output, "URGENT: 2"); later == -1 || urgent == -1 || later > urgent {
+		t.Fatalf("Output should list the tag weights sorted by tag, got: %s", output)
+	}
+
+	for _, secret := range []string{"private", "grpc-private"} {
+		if strings.Contains(output, secret) {
+			t.Fatalf("Output should not contain the credential %q, got: %s", secret, output)
+		}
+	}
+}
+
+func TestConfAllNoTimeout(t *testing.T) {
+	for _, timeout := range []string{"", "soon"} {
+		ui := new(cli.MockUi)
+		c := &command.ConfCommand{
+			Ui:     ui,
+			Config: &command.Config{Timeout: timeout},
+		}
+
+		if code := c.Run([]string{"all"}); code != 0 {
+			t.Fatalf("Expected successful exit code, got %d", code)
+		}
+
+		if output := ui.OutputWriter.String(); !strings.Contains(output, "Timeout: none") {
+			t.Fatalf("Output should contain %q for timeout %q, got: %s", "Timeout: none", timeout, output)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elos/data"
+	"github.com/elos/models"
+	"github.com/mitchellh/cli"
+)
+
+// EventCommand contains the state necessary to implement the
+// 'elos event' command.
+//
+// It implements the cli.Command interface
+type EventCommand struct {
+	// UI is used to communicate (for IO) with the user
+	// It must be non-nil
+	UI cli.Ui
+
+	// UserID is the id of the user we are acting on behalf of.
+	// It must be specified
+	UserID string
+
+	// DB is the elos database we interface with.
+	DB data.DB
+}
+
+// Synopsis is a one-line, short summary of the 'event' command.
+// It is guaranteed to be at most 50 characters.
+func (c *EventCommand) Synopsis() string {
+	return "Utilities for logging events"
+}
+
+// Help is the long-form help text that includes command-line
+// usage. It includes the subcommands and, possible a complete
+// list of flags the 'event' command accepts.
+func (c *EventCommand) Help() string {
+	helpText := `
+Usage:
+	elos event <subcommand>
+
+Subcommands:
+	new [options]	log a new event, which 'elos stream' will pick up
+
+Options:
+	-name <name>	the name of the event, prompted for if missing
+	-tag <name>	tag the event, may be given multiple times
+	-lat <lat>	the latitude of the event's location
+	-lon <lon>	the longitude of the event's location
+	-note <text>	attach a note to the event
+
+If the name isn't given, the name, and optionally the location,
+are prompted for.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Run runs the 'event' command with the given command-line arguments.
+// It returns an exit status when it finishes. 0 indicates a success,
+// any other integer indicates a failure.
+//
+// All user interaction is handled by the command using the UI
+// interface
+func (c *EventCommand) Run(args []string) int {
+	// short circuit to avoid initialization
+	if len(args) == 0 && c.UI != nil {
+		c.UI.Output(c.Help())
+		return success
+	}
+
+	if i := c.init(); i != success {
+		return i
+	}
+
+	switch args[0] {
+	case "new":
+		return c.runNew(args[1:])
+	default:
+		c.UI.Output(c.Help())
+	}
+
+	return success
+}
+
+// errorf calls UI.Error with a formatted, prefixed error string
+// always use it to print an error, avoid using UI.Error directly
+func (c *EventCommand) errorf(format string, values ...interface{}) {
+	c.UI.Error(fmt.Sprintf("(elos event) Error: "+format, values...))
+}
+
+// printf calls UI.Output with the formmated string
+// always prefer printf over c.UI.Output
+func (c *EventCommand) printf(format string, values ...interface{}) {
+	c.UI.Output(fmt.Sprintf(format, values...))
+}
+
+// init ensures we have a UI, DB and UserID, so those can be treated
+// as invariants throughout the rest of the code.
+func (c *EventCommand) init() int {
+	if c.UI == nil {
+		// can't use errorf, because the UI is not defined
+		return failure
+	}
+
+	if c.UserID == "" {
+		c.errorf("no UserID provided")
+		return failure
+	}
+
+	if c.DB == nil {
+		c.errorf("no database")
+		return failure
+	}
+
+	return success
+}
+
+// eventOptions are the options accepted by 'elos event new'
+type eventOptions struct {
+	name string
+	tags []string
+
+	// hasLocation is set if both lat and lon were provided
+	hasLocation bool
+	lat, lon    float64
+
+	note string
+}
+
+// parseEventOptions parses the arguments to 'elos event new'
+func parseEventOptions(args []string) (*eventOptions, error) {
+	opts := new(eventOptions)
+	var hasLat, hasLon bool
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-name", "-tag", "-lat", "-lon", "-note":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s requires a value", args[i])
+			}
+		default:
+			return nil, fmt.Errorf("unrecognized option %q", args[i])
+		}
+
+		flag, value := args[i], args[i+1]
+		i++
+
+		switch flag {
+		case "-name":
+			opts.name = value
+		case "-tag":
+			opts.tags = append(opts.tags, value)
+		case "-lat", "-lon":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s requires a number: %s", flag, err)
+			}
+
+			if flag == "-lat" {
+				opts.lat, hasLat = f, true
+			} else {
+				opts.lon, hasLon = f, true
+			}
+		case "-note":
+			opts.note = value
+		}
+	}
+
+	if hasLat != hasLon {
+		return nil, fmt.Errorf("-lat and -lon must be provided together")
+	}
+	opts.hasLocation = hasLat
+
+	return opts, nil
+}
+
+// promptLocation asks whether the event has a location, and if
+// it does, prompts for the latitude and longitude of it.
+func promptLocation(ui cli.Ui, opts *eventOptions) (err error) {
+	if opts.hasLocation, err = yesNo(ui, "Does it have a location?"); err != nil || !opts.hasLocation {
+		return
+	}
+
+	if opts.lat, err = floatInput(ui, "Latitude"); err != nil {
+		return
+	}
+
+	opts.lon, err = floatInput(ui, "Longitude")
+	return
+}
+
+// findOrCreateTag retrieves the user's tag with the given name,
+// creating it if the user doesn't have one.
+func (c *EventCommand) findOrCreateTag(name string) (*models.Tag, error) {
+	iter, err := c.DB.Query(models.TagKind).Select(data.AttrMap{
+		"owner_id": c.UserID,
+		"name":     name,
+	}).Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	t := models.NewTag()
+	found := iter.Next(t)
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	if found {
+		return t, nil
+	}
+
+	t = models.NewTag()
+	t.SetID(c.DB.NewID())
+	t.OwnerId = c.UserID
+	t.Name = name
+	if err := c.DB.Save(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// runNew runs the 'new' subcommand with the given arguments.
+//
+// The 'new' subcommand creates and saves an event, prompting for
+// the name if it wasn't provided as an option.
+func (c *EventCommand) runNew(args []string) int {
+	opts, err := parseEventOptions(args)
+	if err != nil {
+		c.errorf("%s", err)
+		c.UI.Output(c.Help())
+		return failure
+	}
+
+	if opts.name == "" {
+		if opts.name, err = stringInput(c.UI, "Name"); err != nil {
+			c.errorf("input error: %s", err)
+			return failure
+		}
+
+		if !opts.hasLocation {
+			if err := promptLocation(c.UI, opts); err != nil {
+				c.errorf("input error: %s", err)
+				return failure
+			}
+		}
+	}
+
+	e := models.NewEvent()
+	e.SetID(c.DB.NewID())
+	e.CreatedAt = time.Now()
+	e.OwnerId = c.UserID
+	e.Name = opts.name
+	e.Time = time.Now()
+
+	for _, name := range opts.tags {
+		t, err := c.findOrCreateTag(name)
+		if err != nil {
+			c.errorf("retrieving tag %q: %s", name, err)
+			return failure
+		}
+
+		e.IncludeTag(t)
+	}
+
+	if opts.hasLocation {
+		l := models.NewLocation()
+		l.SetID(c.DB.NewID())
+		l.CreatedAt = time.Now()
+		l.OwnerId = c.UserID
+		l.Latitude = opts.lat
+		l.Longitude = opts.lon
+		l.UpdatedAt = time.Now()
+		if err := c.DB.Save(l); err != nil {
+			c.errorf("saving location: %s", err)
+			return failure
+		}
+
+		e.SetLocation(l)
+	}
+
+	if opts.note != "" {
+		n := models.NewNote()
+		n.SetID(c.DB.NewID())
+		n.CreatedAt = time.Now()
+		n.OwnerId = c.UserID
+		n.Text = opts.note
+		n.UpdatedAt = time.Now()
+		if err := c.DB.Save(n); err != nil {
+			c.errorf("saving note: %s", err)
+			return failure
+		}
+
+		e.SetNote(n)
+	}
+
+	e.UpdatedAt = time.Now()
+	if err := c.DB.Save(e); err != nil {
+		c.errorf("saving event: %s", err)
+		return failure
+	}
+
+	c.printf("Logged %s", e.Name)
+	return success
+}
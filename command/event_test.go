@@ -0,0 +1,167 @@
+package command
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/elos/data"
+	"github.com/elos/data/builtin/mem"
+	"github.com/elos/models"
+	"github.com/mitchellh/cli"
+)
+
+func newMockEventCommand(t *testing.T) (*cli.MockUi, data.DB, *models.User, *EventCommand) {
+	ui := new(cli.MockUi)
+	db := mem.NewDB()
+	user := newTestUser(t, db)
+
+	return ui, db, user, &EventCommand{
+		UI:     ui,
+		UserID: user.ID().String(),
+		DB:     db,
+	}
+}
+
+// --- `elos event new` {{{
+func TestEventNew(t *testing.T) {
+	ui, db, user, c := newMockEventCommand(t)
+
+	food := newTestTag(t, db, user)
+	food.Name = "food"
+	if err := db.Save(food); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("running: `elos event new -name lunch -tag food -tag friends`")
+	code := c.Run([]string{"new", "-name", "lunch", "-tag", "food", "-tag", "friends"})
+	t.Log("command `event new` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	if code != 0 {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	iter, err := db.Query(models.EventKind).Select(data.AttrMap{
+		"owner_id": user.ID().String(),
+	}).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := models.NewEvent()
+	if !iter.Next(e) {
+		t.Fatal("Expected the event to have been saved")
+	}
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := e.Name, "lunch"; got != want {
+		t.Fatalf("e.Name: got %q, want %q", got, want)
+	}
+
+	tags, err := e.Tags(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tags) != 2 {
+		t.Fatalf("Expected 2 tags, got %d", len(tags))
+	}
+
+	names := map[string]bool{}
+	for _, tg := range tags {
+		names[tg.Name] = true
+	}
+
+	// the existing tag is reused, and the missing one is created
+	if !names["food"] || !names["friends"] {
+		t.Fatalf("Expected tags 'food' and 'friends', got %v", names)
+	}
+
+	if tags[0].ID().String() != food.ID().String() && tags[1].ID().String() != food.ID().String() {
+		t.Fatalf("Expected the existing 'food' tag to be reused")
+	}
+}
+
+// --- }}}
+
+// --- `elos event new` (bad location) {{{
+func TestEventNewLatWithoutLon(t *testing.T) {
+	ui, _, _, c := newMockEventCommand(t)
+
+	code := c.Run([]string{"new", "-name", "lunch", "-lat", "1.5"})
+
+	if code == 0 {
+		t.Fatalf("Expected failure exit code")
+	}
+
+	if got, want := ui.ErrorWriter.String(), "(elos event) Error: -lat and -lon must be provided together\n"; got != want {
+		t.Fatalf("error output: got %q, want %q", got, want)
+	}
+}
+
+// --- }}}
+
+// --- `elos event new` (prompted location) {{{
+func TestEventNewPromptedLocation(t *testing.T) {
+	ui, db, user, c := newMockEventCommand(t)
+
+	ui.InputReader = bytes.NewBufferString(strings.Join([]string{
+		"lunch", // name
+		"y",     // has a location?
+		"1.5",   // latitude
+		"2.5",   // longitude
+	}, "\n"))
+
+	t.Log("running: `elos event new`")
+	code := c.Run([]string{"new"})
+	t.Log("command `event new` terminated")
+
+	errput := ui.ErrorWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", ui.OutputWriter.String())
+
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	iter, err := db.Query(models.EventKind).Select(data.AttrMap{
+		"owner_id": user.ID().String(),
+	}).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := models.NewEvent()
+	if !iter.Next(e) {
+		t.Fatal("Expected the event to have been saved")
+	}
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	loc, err := e.Location(db)
+	if err != nil {
+		t.Fatalf("Expected the event to have a location, got: %s", err)
+	}
+
+	if loc.Latitude != 1.5 || loc.Longitude != 2.5 {
+		t.Fatalf("Expected the location (1.5, 2.5), got (%f, %f)", loc.Latitude, loc.Longitude)
+	}
+}
+
+// --- }}}
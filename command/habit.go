@@ -2,6 +2,7 @@ package command
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -30,6 +31,14 @@ type HabitCommand struct {
 
 	// habits is the list of this user's habits
 	habits []*models.Habit
+
+	// pageSize is the number of habits printHabitList prints
+	// at a time, 0 prints them all at once
+	pageSize int
+
+	// verbose indicates printHabitList should also print the
+	// date of each habit's last checkin, which costs a query each
+	verbose bool
 }
 
 // Synopsis is a one-line, short summary of the 'habit' command.
@@ -45,10 +54,13 @@ Usage:
 
 Subcommands:
 	checkin		mark a habit as complete for today
+	checkin -all [-note text]	mark every habit not yet done as complete for today (with a note)
 	delete		delete a habit
 	history		see all checkins for a habit
-	list		list all habits
+	list (-v|--json|--page n)	list all habits (with last checkins, as JSON, n at a time)
 	new		create a new habit
+	report		see how many of the last seven days, today included, each habit was done
+	skip		mark a habit as skipped, rather than missed, for today
 	today		see today's habits and which have been checked off
 `
 	return strings.TrimSpace(helpText)
@@ -83,6 +95,10 @@ func (c *HabitCommand) Run(args []string) int {
 		return c.runList(args)
 	case "new":
 		return c.runNew(args)
+	case "report":
+		return c.runReport(args)
+	case "skip":
+		return c.runSkip(args)
 	case "today":
 		return c.runToday(args)
 	default:
@@ -160,9 +176,81 @@ func (c *HabitCommand) init() int {
 
 // printHabitList prints a numbered list of the habits in the habits slice
 func (c *HabitCommand) printHabitList() {
-	for i, h := range c.habits {
-		c.printf("%d) %s", i, h.Name)
+	// an input error just stops the paging early
+	page(c.UI, len(c.habits), c.pageSize, func(i int) {
+		if !c.verbose {
+			c.printf("%d) %s", i, c.habits[i].Name)
+			return
+		}
+
+		last, err := c.lastCheckin(c.habits[i])
+		switch {
+		case err != nil:
+			c.errorf("retrieving checkins of %s: %s", c.habits[i].Name, err)
+		case last.IsZero():
+			c.printf("%d) %s (never)", i, c.habits[i].Name)
+		default:
+			c.printf("%d) %s (last: %s)", i, c.habits[i].Name, last.Local().Format("Mon Jan 2"))
+		}
+	})
+}
+
+// skipNote is the text of the note which marks a checkin event as a skip,
+// a day on which the habit was deliberately not done. A skip is neither
+// a checkin nor a miss.
+const skipNote = "SKIP"
+
+// checkins retrieves the habit's checkin events, separating the skips
+// from the actual checkins.
+func (c *HabitCommand) checkins(h *models.Habit) (checkins, skips []*models.Event, err error) {
+	events, err := h.Checkins(c.DB)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, e := range events {
+		n, err := e.Note(c.DB)
+		if err != nil && err != models.ErrEmptyLink {
+			return nil, nil, err
+		}
+
+		if n != nil && n.Text == skipNote {
+			skips = append(skips, e)
+		} else {
+			checkins = append(checkins, e)
+		}
 	}
+
+	return checkins, skips, nil
+}
+
+// onDay indicates whether any of the events happened on the same day as t
+func onDay(events []*models.Event, t time.Time) bool {
+	for _, e := range events {
+		if dayEquivalent(e.Time.Local(), t.Local()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lastCheckin retrieves the time of the habit's most recent checkin,
+// which is the zero time if the habit has never been checked in.
+func (c *HabitCommand) lastCheckin(h *models.Habit) (time.Time, error) {
+	checkins, _, err := c.checkins(h)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var last time.Time
+	for _, e := range checkins {
+		if e.Time.After(last) {
+			last = e.Time
+		}
+	}
+
+	return last, nil
 }
 
 // promptSelectHabit prompts the user to select a habits from their list
@@ -249,6 +337,10 @@ func (c *HabitCommand) promptNewHabit() (*models.Habit, int) {
 }
 
 func (c *HabitCommand) runCheckin(args []string) int {
+	if hasFlag(args, "all") {
+		return c.runCheckinAll(args)
+	}
+
 	hbt, index := c.promptSelectHabit()
 	if index < 0 {
 		return failure
@@ -262,6 +354,49 @@ func (c *HabitCommand) runCheckin(args []string) int {
 	return success
 }
 
+// runCheckinAll runs 'checkin -all', which checks in every habit which
+// hasn't already been checked in (or skipped) today, all with the note
+// given by -note, if any.
+func (c *HabitCommand) runCheckinAll(args []string) int {
+	note, _, err := stringFlag(args, "note", "")
+	if err != nil {
+		c.errorf("%s", err)
+		return failure
+	}
+
+	var (
+		now     = time.Now()
+		checked int
+		done    []string
+	)
+
+	for _, h := range c.habits {
+		already, err := habit.DidCheckinOn(c.DB, h, now)
+		if err != nil {
+			c.errorf("checking if %s was done today: %s", h.Name, err)
+			return failure
+		}
+
+		if already {
+			done = append(done, h.Name)
+			continue
+		}
+
+		if _, err := habit.CheckinFor(c.DB, h, note, now); err != nil {
+			c.errorf("while checking in %s: %s", h.Name, err)
+			return failure
+		}
+		checked++
+	}
+
+	c.printf("Checked in %d habits", checked)
+	if len(done) > 0 {
+		c.printf("Skipped, as already done today: %s", strings.Join(done, ", "))
+	}
+
+	return success
+}
+
 func (c *HabitCommand) runDelete(args []string) int {
 	habit, index := c.promptSelectHabit()
 	if index < 0 {
@@ -293,7 +428,7 @@ func (c *HabitCommand) runHistory(args []string) int {
 
 	checkins, err := habit.Checkins(c.DB)
 	if err != nil {
-		c.errorf("while retrieving checkins")
+		c.errorf("while retrieving checkins: %s", err)
 		return failure
 	}
 
@@ -303,9 +438,15 @@ func (c *HabitCommand) runHistory(args []string) int {
 	}
 
 	for _, event := range checkins {
+		n, err := event.Note(c.DB)
+		if err == nil && n.Text == skipNote {
+			c.printf("Skipped on %s", event.Time.Local().Format("Mon Jan 2 15:04"))
+			continue
+		}
+
 		c.printf("Checkin on %s", event.Time.Local().Format("Mon Jan 2 15:04"))
 
-		if n, err := event.Note(c.DB); err != nil {
+		if err != nil {
 			c.errorf("error retrieving event's note: %s", err)
 		} else if n.Text != "" {
 			c.printf("\tNotes: %s", n.Text)
@@ -316,11 +457,27 @@ func (c *HabitCommand) runHistory(args []string) int {
 }
 
 func (c *HabitCommand) runList(args []string) int {
+	if hasFlag(args, "json") {
+		if err := outputJSON(c.UI, c.habits); err != nil {
+			c.errorf("encoding habits: %s", err)
+			return failure
+		}
+		return success
+	}
+
 	if len(c.habits) == 0 {
 		c.printf("You have no habits")
 		return success
 	}
 
+	size, err := pageFlag(args)
+	if err != nil {
+		c.errorf("%s", err)
+		return failure
+	}
+	c.pageSize = size
+	c.verbose = hasFlag(args, "v")
+
 	c.printf("Here are your habits:")
 	c.printHabitList()
 	return success
@@ -336,16 +493,138 @@ func (c *HabitCommand) runNew(args []string) int {
 	return success
 }
 
+// reportDays is the number of days, up to and including today,
+// covered by the 'report' subcommand
+const reportDays = 7
+
+// habitReport is a habit's line of the 'report' subcommand
+type habitReport struct {
+	name string
+
+	// done is the number of days the habit was checked in, out of
+	// days, the number of days which weren't skipped
+	done, days int
+}
+
+// rate is the fraction of days the habit was done, a habit
+// which was skipped every day is considered fully done
+func (r habitReport) rate() float64 {
+	if r.days == 0 {
+		return 1
+	}
+
+	return float64(r.done) / float64(r.days)
+}
+
+// byRate sorts habit reports by rate, lowest first, then by name
+type byRate []habitReport
+
+func (b byRate) Len() int      { return len(b) }
+func (b byRate) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byRate) Less(i, j int) bool {
+	if b[i].rate() != b[j].rate() {
+		return b[i].rate() < b[j].rate()
+	}
+
+	return b[i].name < b[j].name
+}
+
+// runReport runs the 'report' subcommand, which prints on how many of
+// the last seven days each habit was checked in, i.e., "Meditate: 5/7",
+// worst first. Skipped days are neither counted as done nor as missed.
+//
+// The seven days are a rolling window ending today, rather than the
+// calendar week, and days are in local time, as for 'checkin' and 'today'.
+func (c *HabitCommand) runReport(args []string) int {
+	if len(c.habits) == 0 {
+		c.printf("You have no habits")
+		return success
+	}
+
+	now := time.Now()
+	reports := make([]habitReport, 0, len(c.habits))
+	for _, h := range c.habits {
+		checkins, skips, err := c.checkins(h)
+		if err != nil {
+			c.errorf("retrieving checkins of %s: %s", h.Name, err)
+			return failure
+		}
+
+		r := habitReport{name: h.Name}
+		for i := 0; i < reportDays; i++ {
+			day := now.AddDate(0, 0, -i)
+			switch {
+			case onDay(checkins, day):
+				r.done++
+				r.days++
+			case onDay(skips, day):
+			default:
+				r.days++
+			}
+		}
+
+		reports = append(reports, r)
+	}
+
+	sort.Sort(byRate(reports))
+
+	c.printf("The last %d days:", reportDays)
+	for _, r := range reports {
+		c.printf("%s: %d/%d", r.name, r.done, r.days)
+	}
+
+	return success
+}
+
+// runSkip runs the 'skip' subcommand, which marks a habit as skipped
+// for today, so that not doing it today isn't counted as a miss.
+func (c *HabitCommand) runSkip(args []string) int {
+	hbt, index := c.promptSelectHabit()
+	if index < 0 {
+		return failure
+	}
+
+	checkins, skips, err := c.checkins(hbt)
+	if err != nil {
+		c.errorf("retrieving checkins: %s", err)
+		return failure
+	}
+
+	if onDay(checkins, time.Now()) {
+		c.UI.Warn(fmt.Sprintf("You already checked in %s today", hbt.Name))
+		return success
+	}
+
+	if onDay(skips, time.Now()) {
+		c.UI.Warn(fmt.Sprintf("You already skipped %s today", hbt.Name))
+		return success
+	}
+
+	if _, err := habit.CheckinFor(c.DB, hbt, skipNote, time.Now()); err != nil {
+		c.errorf("while skipping: %s", err)
+		return failure
+	}
+
+	c.printf("Skipped %s for today", hbt.Name)
+	return success
+}
+
 func (c *HabitCommand) runToday(args []string) int {
 	c.printf("Here is today's lineup:")
 	var complete string
 	for _, h := range c.habits {
-		if checkedIn, err := habit.DidCheckinOn(c.DB, h, time.Now()); err != nil {
+		checkins, skips, err := c.checkins(h)
+		if err != nil {
 			c.errorf("error checking if habit is complete: %s", err)
 			return failure
-		} else if checkedIn {
-			complete = "✓"
-		} else {
+		}
+
+		switch {
+		case onDay(checkins, time.Now()):
+			complete = colorize(colorGreen, "✓")
+		case onDay(skips, time.Now()):
+			complete = "skipped"
+		default:
 			complete = ""
 		}
 
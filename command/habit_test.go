@@ -2,6 +2,7 @@ package command
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
@@ -156,6 +157,70 @@ func TestHabitCheckin(t *testing.T) {
 	}
 }
 
+// TestHabitCheckinAll tests that `checkin -all` checks in only
+// the habits which weren't already checked in today
+func TestHabitCheckinAll(t *testing.T) {
+	ui, db, user, c := newMockHabitCommand(t)
+
+	done := newTestHabit(t, db, user, "done")
+	todo := newTestHabit(t, db, user, "todo")
+	if _, err := habit.CheckinFor(db, done, "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("running: `elos habit checkin -all -note shared`")
+	code := c.Run([]string{"checkin", "-all", "-note", "shared"})
+	t.Log("command `checkin` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	if !strings.Contains(output, "Checked in 1 habits") {
+		t.Fatal("Output should have reported one habit checked in")
+	}
+
+	if !strings.Contains(output, "already done today: done") {
+		t.Fatal("Output should have reported 'done' as already done")
+	}
+
+	// 'done' must not have been checked in twice
+	if checkins, _, err := c.checkins(done); err != nil {
+		t.Fatal(err)
+	} else if len(checkins) != 1 {
+		t.Fatalf("Expected 'done' to still have 1 checkin, got %d", len(checkins))
+	}
+
+	checkins, _, err := c.checkins(todo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(checkins) != 1 {
+		t.Fatalf("Expected 'todo' to have 1 checkin, got %d", len(checkins))
+	}
+
+	n, err := checkins[0].Note(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n.Text != "shared" {
+		t.Fatalf("Expected the checkin to have the shared note, got %q", n.Text)
+	}
+}
+
 // --- }}}
 
 // --- `elos habit delete` {{{
@@ -300,6 +365,82 @@ func TestHabitList(t *testing.T) {
 	}
 }
 
+// TestHabitListVerbose tests the `list -v` subcommand
+func TestHabitListVerbose(t *testing.T) {
+	ui, db, user, c := newMockHabitCommand(t)
+
+	checked := newTestHabit(t, db, user, "Habit 1")
+	newTestHabit(t, db, user, "Habit 2")
+
+	last := time.Date(2020, time.January, 6, 12, 0, 0, 0, time.Local)
+	if _, err := habit.CheckinFor(db, checked, "", last.AddDate(0, 0, -1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := habit.CheckinFor(db, checked, "", last); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("running: `elos habit list -v`")
+	code := c.Run([]string{"list", "-v"})
+	t.Log("command `list -v` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	if !strings.Contains(output, "Habit 1 (last: Mon Jan 6)") {
+		t.Fatalf("Output should have the date of the last checkin of 'Habit 1'")
+	}
+
+	if !strings.Contains(output, "Habit 2 (never)") {
+		t.Fatalf("Output should have said 'Habit 2' was never checked in")
+	}
+}
+
+// TestHabitListJSON tests the `list --json` subcommand
+func TestHabitListJSON(t *testing.T) {
+	ui, db, user, c := newMockHabitCommand(t)
+
+	newTestHabit(t, db, user, "Habit 1")
+
+	t.Log("running: `elos habit list --json`")
+	code := c.Run([]string{"list", "--json"})
+	t.Log("command `list --json` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	var habits []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(output), &habits); err != nil {
+		t.Fatalf("Output should be valid JSON: %s", err)
+	}
+
+	if len(habits) != 1 || habits[0].Name != "Habit 1" {
+		t.Fatalf("Expected the JSON to contain the habit 'Habit 1', got: %+v", habits)
+	}
+}
+
 // --- }}}
 
 // --- `elos habit new` {{{
@@ -354,6 +495,108 @@ func TestHabitNew(t *testing.T) {
 
 // --- }}}
 
+// --- `elos habit report` {{{
+func TestHabitReport(t *testing.T) {
+	ui, db, user, c := newMockHabitCommand(t)
+
+	often := newTestHabit(t, db, user, "Often")
+	rarely := newTestHabit(t, db, user, "Rarely")
+	newTestHabit(t, db, user, "Never")
+
+	now := time.Now()
+
+	// twice on the same day counts once
+	for _, daysAgo := range []int{0, 0, 1, 2, 3, 4} {
+		if _, err := habit.CheckinFor(db, often, "", now.AddDate(0, 0, -daysAgo)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a week ago is out of the report
+	for _, daysAgo := range []int{1, 7} {
+		if _, err := habit.CheckinFor(db, rarely, "", now.AddDate(0, 0, -daysAgo)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Log("running: `elos habit report`")
+	code := c.Run([]string{"report"})
+	t.Log("command `report` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	want := "The last 7 days:\nNever: 0/7\nRarely: 1/7\nOften: 5/7\n"
+	if output != want {
+		t.Fatalf("output: got %q, want %q", output, want)
+	}
+}
+
+// --- }}}
+
+// --- `elos habit skip` {{{
+func TestHabitSkip(t *testing.T) {
+	ui, db, user, c := newMockHabitCommand(t)
+
+	hbt := newTestHabit(t, db, user, "Test Habit")
+
+	ui.InputReader = bytes.NewBufferString("0\n")
+
+	t.Log("running: `elos habit skip`")
+	code := c.Run([]string{"skip"})
+	t.Log("command `skip` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	checkins, skips, err := c.checkins(hbt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(checkins) != 0 || len(skips) != 1 {
+		t.Fatalf("Expected one skip and no checkins, got %d skips and %d checkins", len(skips), len(checkins))
+	}
+
+	// today shows the habit as skipped, not checked off
+	ui = new(cli.MockUi)
+	c.UI = ui
+
+	if code := c.Run([]string{"today"}); code != success {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	if output := ui.OutputWriter.String(); !strings.Contains(output, "Test Habit: skipped") {
+		t.Fatalf("Output should have shown the habit as skipped, got: %s", output)
+	}
+}
+
+// --- }}}
+
 // --- `elos habit today` {{{
 func TestHabitToday(t *testing.T) {
 	ui, db, user, c := newMockHabitCommand(t)
@@ -1,6 +1,8 @@
 package command
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -9,6 +11,11 @@ import (
 	"github.com/mitchellh/cli"
 )
 
+// ErrCancelled is returned by the input functions which allow the
+// user to cancel, by entering "q". Callers should treat it as a clean
+// abort of what they were doing, rather than as an error.
+var ErrCancelled = errors.New("input cancelled")
+
 // yesNo requests confirmation of something
 //
 // Use this for deciding what to do, like whether to request
@@ -33,19 +40,28 @@ func stringInput(ui cli.Ui, text string) (string, error) {
 // such as the case where you want to take a list of strings
 //
 // It parses the strings based on commas, or double commas,
-// if the string input needs to include commas
+// if the string input needs to include commas. Whitespace
+// around each string is trimmed, and empty strings dropped.
 func stringListInput(ui cli.Ui, text string) ([]string, error) {
 	in, err := ui.Ask(text + " [list,of,strings]")
 	if err != nil {
 		return nil, err
 	}
 
+	sep := ","
 	// if the user used double commas, single commas will be ignored
 	if strings.Contains(in, ",,") {
-		return strings.Split(in, ",,"), nil
+		sep = ",,"
 	}
 
-	return strings.Split(in, ","), nil
+	list := make([]string, 0)
+	for _, s := range strings.Split(in, sep) {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+
+	return list, nil
 }
 
 // boolInput requests a boolean input
@@ -78,14 +94,19 @@ func boolInput(ui cli.Ui, text string) (bool, error) {
 
 // intInput requests an integer input (signed)
 //
-// Use intInput if you need to retrieve an integer.
+// Use intInput if you need to retrieve an integer. The user may
+// enter "q" to cancel, in which case the error is ErrCancelled.
 func intInput(ui cli.Ui, text string) (int, error) {
 	for {
-		input, err := ui.Ask(text + " [integer]:")
+		input, err := ui.Ask(text + " [integer, q to cancel]:")
 		if err != nil {
 			return 0, err
 		}
 
+		if input == "q" {
+			return 0, ErrCancelled
+		}
+
 		i64, err := strconv.ParseInt(input, 10, 64)
 		if err == nil {
 			return int(i64), nil
@@ -96,6 +117,50 @@ func intInput(ui cli.Ui, text string) (int, error) {
 	}
 }
 
+// floatInput requests a floating point number input
+//
+// Use floatInput if you need to retrieve a real number, such as
+// a coordinate. As with intInput, the user may cancel.
+func floatInput(ui cli.Ui, text string) (float64, error) {
+	for {
+		input, err := ui.Ask(text + " [number, q to cancel]:")
+		if err != nil {
+			return 0, err
+		}
+
+		if input == "q" {
+			return 0, ErrCancelled
+		}
+
+		f, err := strconv.ParseFloat(input, 64)
+		if err == nil {
+			return f, nil
+		}
+
+		ui.Output("Invalid input, please try again. Valid number expressions include: 1, 1.5, -37.42 etc.")
+	}
+}
+
+// intRangeInput requests an integer input in the range [min, max],
+// prompting again if the integer is out of range.
+//
+// Use intRangeInput if the integer must be bounded, such as when
+// selecting an index. As with intInput, the user may cancel.
+func intRangeInput(ui cli.Ui, text string, min, max int) (int, error) {
+	for {
+		i, err := intInput(ui, text)
+		if err != nil {
+			return 0, err
+		}
+
+		if min <= i && i <= max {
+			return i, nil
+		}
+
+		ui.Output(fmt.Sprintf("Invalid input, please try again. Need a # in (%d,...,%d)", min, max))
+	}
+}
+
 // timeInput retrieves a time.Time value, but only pays attention
 // to the hour and the minute components. It fills in the year 0,
 // month 0, day 0, second 0 and nsecond 0. It uses time.Local for
@@ -105,6 +170,11 @@ func intInput(ui cli.Ui, text string) (int, error) {
 // but if you care also abou the calendrical components, such as
 // the year, month and day, use 'dateInput'.
 func timeInput(ui cli.Ui, text string) (t time.Time, err error) {
+	return timeInputIn(ui, text, time.Local)
+}
+
+// timeInputIn is timeInput, except the time is in the given location.
+func timeInputIn(ui cli.Ui, text string, loc *time.Location) (t time.Time, err error) {
 	ui.Output(text + " [time]")
 
 	var (
@@ -112,15 +182,15 @@ func timeInput(ui cli.Ui, text string) (t time.Time, err error) {
 		hour, min int
 	)
 
-	if hour, inputErr = intInput(ui, "Hour [e.g., 13]"); inputErr != nil {
+	if hour, inputErr = intRangeInput(ui, "Hour [e.g., 13]", 0, 23); inputErr != nil {
 		return *new(time.Time), inputErr
 	}
 
-	if min, inputErr = intInput(ui, "Minute [e.g., 59]"); inputErr != nil {
+	if min, inputErr = intRangeInput(ui, "Minute [e.g., 59]", 0, 59); inputErr != nil {
 		return *new(time.Time), inputErr
 	}
 
-	return time.Date(0, 0, 0, hour, min, 0, 0, time.Local), nil
+	return time.Date(0, 0, 0, hour, min, 0, 0, loc), nil
 }
 
 // dateInput retrieves a time.Time value as textual input over a
@@ -129,6 +199,11 @@ func timeInput(ui cli.Ui, text string) (t time.Time, err error) {
 // Use dateInput when you need a full date and time, i.e., 1/1/1 12:00
 // If you only need a time, use 'timeInput'.
 func dateInput(ui cli.Ui, text string) (time.Time, error) {
+	return dateInputIn(ui, text, time.Local)
+}
+
+// dateInputIn is dateInput, except the date is in the given location.
+func dateInputIn(ui cli.Ui, text string, loc *time.Location) (time.Time, error) {
 	ui.Output(text + " [date]")
 
 	var (
@@ -136,27 +211,121 @@ func dateInput(ui cli.Ui, text string) (time.Time, error) {
 		year, month, day, hour, min int
 	)
 
-	if year, inputErr = intInput(ui, "Year (e.g., 2016)"); inputErr != nil {
+	if year, inputErr = intRangeInput(ui, "Year (e.g., 2016)", 1000, 9999); inputErr != nil {
 		return *new(time.Time), inputErr
 	}
 
-	if month, inputErr = intInput(ui, "Month (e.g., 1 for January)"); inputErr != nil {
+	if month, inputErr = intRangeInput(ui, "Month (e.g., 1 for January)", 1, 12); inputErr != nil {
 		return *new(time.Time), inputErr
 	}
 
-	if day, inputErr = intInput(ui, "Day [e.g., 1]"); inputErr != nil {
+	if day, inputErr = intRangeInput(ui, "Day [e.g., 1]", 1, daysIn(time.Month(month), year)); inputErr != nil {
 		return *new(time.Time), inputErr
 	}
 
-	if hour, inputErr = intInput(ui, "Hour [e.g., 13]"); inputErr != nil {
+	if hour, inputErr = intRangeInput(ui, "Hour [e.g., 13]", 0, 23); inputErr != nil {
 		return *new(time.Time), inputErr
 	}
 
-	if min, inputErr = intInput(ui, "Minute [e.g., 59]"); inputErr != nil {
+	if min, inputErr = intRangeInput(ui, "Minute [e.g., 59]", 0, 59); inputErr != nil {
 		return *new(time.Time), inputErr
 	}
 
-	return time.Date(year, time.Month(month), day, hour, min, 0, 0, time.Local), nil
+	return time.Date(year, time.Month(month), day, hour, min, 0, 0, loc), nil
+}
+
+// daysIn returns the number of days in the month of the year
+func daysIn(m time.Month, year int) int {
+	// the zeroth day of the next month is the last day of this one
+	return time.Date(year, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// parseDate parses a date given as a command-line argument
+//
+// It accepts dates of the form 2006-01-02, the relative dates
+// "today", "tomorrow" and "yesterday", and offsets in days from
+// now, such as "+3d" or "-2d". Dates are in now's location, and
+// the time of day is taken from now.
+//
+// Use parseDate for optional date arguments, like those to
+// 'elos cal2 day'; to prompt for a date use 'dateInput'.
+func parseDate(s string, now time.Time) (time.Time, error) {
+	switch s {
+	case "today":
+		return now, nil
+	case "tomorrow":
+		return now.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1), nil
+	}
+
+	if (strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-")) && strings.HasSuffix(s, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(s, "d")); err == nil {
+			return now.AddDate(0, 0, days), nil
+		}
+	}
+
+	d, err := time.ParseInLocation("2006-01-02", s, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD, today, tomorrow, yesterday or +/-Nd", s)
+	}
+
+	return time.Date(d.Year(), d.Month(), d.Day(), now.Hour(), now.Minute(), now.Second(), 0, now.Location()), nil
+}
+
+// parseDuration parses a duration given as a command-line argument
+//
+// It accepts a number of weeks, days or hours, such as "2w", "3d" or
+// "12h", as well as any duration time.ParseDuration understands, such
+// as "1h30m". A day is taken to be 24 hours.
+func parseDuration(s string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"w": 7 * 24 * time.Hour,
+		"d": 24 * time.Hour,
+		"h": time.Hour,
+	}
+
+	for suffix, unit := range units {
+		if !strings.HasSuffix(s, suffix) {
+			continue
+		}
+
+		if n, err := strconv.Atoi(strings.TrimSuffix(s, suffix)); err == nil {
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q, expected i.e., 2w, 3d, 12h or 1h30m", s)
+	}
+
+	return d, nil
+}
+
+// durationInput requests a duration input, in any form
+// parseDuration understands
+//
+// Use durationInput if you need to retrieve a duration. The user may
+// enter "q" to cancel, in which case the error is ErrCancelled.
+func durationInput(ui cli.Ui, text string) (time.Duration, error) {
+	for {
+		input, err := ui.Ask(text + " [i.e., 3d, 12h, 2w, q to cancel]:")
+		if err != nil {
+			return 0, err
+		}
+
+		if input == "q" {
+			return 0, ErrCancelled
+		}
+
+		d, err := parseDuration(input)
+		if err == nil {
+			return d, nil
+		}
+
+		ui.Output(fmt.Sprintf("Invalid input, please try again. %s", err))
+	}
 }
 
 func timestamp(t time.Time, err error) (*models.Timestamp, error) {
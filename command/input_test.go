@@ -0,0 +1,209 @@
+package command
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2016, time.March, 10, 13, 30, 0, 0, time.Local)
+
+	cases := map[string]struct {
+		in   string
+		want time.Time
+		err  bool
+	}{
+		"today":     {in: "today", want: now},
+		"tomorrow":  {in: "tomorrow", want: now.AddDate(0, 0, 1)},
+		"yesterday": {in: "yesterday", want: now.AddDate(0, 0, -1)},
+		"plus days": {in: "+3d", want: now.AddDate(0, 0, 3)},
+		"less days": {in: "-2d", want: now.AddDate(0, 0, -2)},
+		"absolute":  {in: "2020-01-05", want: time.Date(2020, time.January, 5, 13, 30, 0, 0, time.Local)},
+		"garbage":   {in: "next thursday", err: true},
+		"bad month": {in: "2020-13-05", err: true},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			got, err := parseDate(c.in, now)
+			if c.err {
+				if err == nil {
+					t.Fatalf("parseDate(%q): expected an error", c.in)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseDate(%q) error: %v", c.in, err)
+			}
+
+			if !got.Equal(c.want) {
+				t.Fatalf("parseDate(%q): got %v, want %v", c.in, got, c.want)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]struct {
+		in   string
+		want time.Duration
+		err  bool
+	}{
+		"weeks":   {in: "2w", want: 14 * 24 * time.Hour},
+		"days":    {in: "3d", want: 72 * time.Hour},
+		"hours":   {in: "12h", want: 12 * time.Hour},
+		"mixed":   {in: "1h30m", want: 90 * time.Minute},
+		"garbage": {in: "a while", err: true},
+		"no unit": {in: "3", err: true},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			got, err := parseDuration(c.in)
+			if c.err {
+				if err == nil {
+					t.Fatalf("parseDuration(%q): expected an error", c.in)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseDuration(%q) error: %v", c.in, err)
+			}
+
+			if got != c.want {
+				t.Fatalf("parseDuration(%q): got %v, want %v", c.in, got, c.want)
+			}
+		})
+	}
+}
+
+func TestIntInput(t *testing.T) {
+	cases := map[string]struct {
+		in   string
+		want int
+		err  error
+	}{
+		"integer":       {in: "12\n", want: 12},
+		"retry":         {in: "twelve\n12\n", want: 12},
+		"cancel":        {in: "q\n", err: ErrCancelled},
+		"retry, cancel": {in: "twelve\nq\n", err: ErrCancelled},
+		"negative":      {in: "-300\n", want: -300},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			ui := &cli.MockUi{InputReader: bytes.NewBufferString(c.in)}
+
+			got, err := intInput(ui, "?")
+			if err != c.err {
+				t.Fatalf("intInput error: got %v, want %v", err, c.err)
+			}
+
+			if got != c.want {
+				t.Fatalf("intInput: got %d, want %d", got, c.want)
+			}
+		})
+	}
+}
+
+func TestIntRangeInput(t *testing.T) {
+	ui := &cli.MockUi{InputReader: bytes.NewBufferString("7\n-1\n2\n")}
+
+	got, err := intRangeInput(ui, "?", 0, 3)
+	if err != nil {
+		t.Fatalf("intRangeInput error: %v", err)
+	}
+
+	if got != 2 {
+		t.Fatalf("intRangeInput: got %d, want 2", got)
+	}
+}
+
+func TestTimeInput(t *testing.T) {
+	// an invalid hour, then an invalid minute
+	ui := &cli.MockUi{InputReader: bytes.NewBufferString("25\n13\n70\n45\n")}
+
+	got, err := timeInput(ui, "?")
+	if err != nil {
+		t.Fatalf("timeInput error: %v", err)
+	}
+
+	if got.Hour() != 13 || got.Minute() != 45 {
+		t.Fatalf("timeInput: got %v, want 13:45", got)
+	}
+}
+
+func TestDateInput(t *testing.T) {
+	// an invalid month, then a day not in (leap) february
+	ui := &cli.MockUi{InputReader: bytes.NewBufferString("2016\n13\n2\n30\n29\n10\n30\n")}
+
+	got, err := dateInput(ui, "?")
+	if err != nil {
+		t.Fatalf("dateInput error: %v", err)
+	}
+
+	if want := time.Date(2016, time.February, 29, 10, 30, 0, 0, time.Local); !got.Equal(want) {
+		t.Fatalf("dateInput: got %v, want %v", got, want)
+	}
+}
+
+func TestDateInputIn(t *testing.T) {
+	loc := time.FixedZone("UTC+5", 5*60*60)
+	ui := &cli.MockUi{InputReader: bytes.NewBufferString("2016\n3\n10\n9\n0\n")}
+
+	got, err := dateInputIn(ui, "?", loc)
+	if err != nil {
+		t.Fatalf("dateInputIn error: %v", err)
+	}
+
+	if got.Location() != loc {
+		t.Fatalf("dateInputIn: got location %v, want %v", got.Location(), loc)
+	}
+
+	// 9:00 in UTC+5 is 4:00 in UTC
+	if want := time.Date(2016, time.March, 10, 4, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("dateInputIn: got %v, want %v", got, want)
+	}
+}
+
+// answerUI answers every question with the same answer, unlike
+// cli.MockUi it can answer with whitespace
+type answerUI struct {
+	cli.MockUi
+	answer string
+}
+
+func (ui *answerUI) Ask(string) (string, error) {
+	return ui.answer, nil
+}
+
+func TestStringListInput(t *testing.T) {
+	cases := map[string]struct {
+		in   string
+		want []string
+	}{
+		"plain":         {in: "a,b", want: []string{"a", "b"}},
+		"whitespace":    {in: " a , b ", want: []string{"a", "b"}},
+		"empty entries": {in: "a, ,b,", want: []string{"a", "b"}},
+		"double commas": {in: "a, b,, c", want: []string{"a, b", "c"}},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			got, err := stringListInput(&answerUI{answer: c.in}, "?")
+			if err != nil {
+				t.Fatalf("stringListInput error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("stringListInput: got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package command
 
 import (
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
@@ -10,10 +11,21 @@ import (
 	"github.com/mitchellh/cli"
 )
 
+// NoteCommand contains the state necessary to implement the
+// 'elos note' command.
+//
+// It implements the cli.Command interface
 type NoteCommand struct {
-	Ui cli.Ui
-	*Config
-	data.DB
+	// UI is used to communicate (for IO) with the user
+	// It must be non-nil
+	UI cli.Ui
+
+	// UserID is the id of the user we are acting on behalf of.
+	// It must be specified
+	UserID string
+
+	// DB is the elos database we interface with.
+	DB data.DB
 }
 
 func (c *NoteCommand) Help() string {
@@ -22,119 +34,255 @@ Usage:
 	elos note <subcommand>
 
 Available subcommands:
+	list		list your notes, to delete or edit one
+	new		make a new note
+	search <query>	search your notes, to delete or edit one
 `
 	return strings.TrimSpace(helpText)
 }
 
 func (c *NoteCommand) Run(args []string) int {
+	if c.UI == nil {
+		log.Print("(elos note): no ui")
+		return failure
+	}
+
 	switch len(args) {
 	case 0:
-		c.Ui.Output(c.Help())
-	case 1:
+		c.UI.Output(c.Help())
+	default:
 		if c.DB == nil {
-			c.Ui.Error("No database listed")
-			return 1
+			c.UI.Error("No database listed")
+			return failure
 		}
 
-		if c.Config.UserID == "" {
-			c.Ui.Error("No user id listed")
-			return 1
+		if c.UserID == "" {
+			c.UI.Error("No user id listed")
+			return failure
 		}
 
 		switch args[0] {
 		case "new":
-			text, err := c.Ui.Ask("What would you like to make note of?:")
+			text, err := c.UI.Ask("What would you like to make note of?:")
 			if err != nil {
-				return 1
+				return failure
 			}
 
 			note := models.NewNote()
 			note.SetID(c.DB.NewID())
-			note.OwnerId = c.Config.UserID
+			note.OwnerId = c.UserID
 			note.CreatedAt = time.Now()
 			note.Text = text
 			note.UpdatedAt = time.Now()
 
-			err = c.DB.Save(note)
-			if err != nil {
-				c.Ui.Error("Failed to save note")
-				return 1
+			if i := c.promptTagNote(note); i != success {
+				return i
 			}
 
-			c.Ui.Output("Noted")
-		case "list":
-			q := c.DB.Query(models.NoteKind)
-			q.Select(data.AttrMap{
-				"owner_id": c.Config.UserID,
-			})
-			iter, err := q.Execute()
+			err = c.DB.Save(note)
 			if err != nil {
-				c.Ui.Error(fmt.Sprintf("Error executing query: %s", err))
-				return 1
+				c.UI.Error("Failed to save note")
+				return failure
 			}
 
-			n := models.NewNote()
-			notes := make([]*models.Note, 0)
-			for iter.Next(n) {
-				notes = append(notes, n)
-				n = models.NewNote()
+			if i := c.promptAttachNote(note); i != success {
+				return i
 			}
 
-			if err := iter.Close(); err != nil {
-				c.Ui.Error(fmt.Sprintf("Error executing query: %s", err))
-				return 1
+			c.UI.Output("Noted")
+		case "list":
+			notes, i := c.loadNotes()
+			if i != success {
+				return i
 			}
 
-			c.Ui.Output("Here are your notes")
-			for i := range notes {
-				c.Ui.Output(fmt.Sprintf("-----------%d-------------", i))
-				c.Ui.Output(notes[i].Text)
-			}
+			c.UI.Output("Here are your notes")
+			c.printNotes(notes)
 
-			t, err := c.Ui.Ask("Would you like to [D]elete or [E]dit any? (enter to continue)")
-			if err != nil {
-				return 1
-			}
+			return c.promptChangeNote(notes)
+		case "search":
+			return c.runSearch(args[1:])
+		}
+	}
 
-			var i int
-			if t != "" {
-				i, err = intInput(c.Ui, "Which one?")
-				if err != nil {
-					return -1
-				}
-			}
+	return success
+}
 
-			switch t {
-			case "d":
-				fallthrough
-			case "D":
-
-				err = c.DB.Delete(notes[i])
-				if err != nil {
-					c.Ui.Error("Error deleting the note")
-					return -1
-				}
-			case "e":
-				fallthrough
-			case "E":
-				c.Ui.Output(fmt.Sprintf("Current text is: %s", notes[i].Text))
-				text, err := c.Ui.Ask("What would you like instead?:")
-				if err != nil {
-					return -1
-				}
-
-				notes[i].Text = text
-				notes[i].UpdatedAt = time.Now()
-				err = c.DB.Save(notes[i])
-				if err != nil {
-					c.Ui.Error(fmt.Sprintf("Error saving record: %s", err))
-					return -1
-				}
-			}
+// runSearch runs the 'search' subcommand, which lists the user's notes
+// containing the query, case-insensitively, to delete or edit one
+// of them as 'list' does. The query is prompted for if not given.
+func (c *NoteCommand) runSearch(args []string) int {
+	query := strings.Join(args, " ")
+	if query == "" {
+		var err error
+		if query, err = stringInput(c.UI, "What would you like to search for?"); err != nil {
+			c.UI.Error(fmt.Sprintf("Input error: %s", err))
+			return failure
+		}
+	}
+
+	notes, i := c.loadNotes()
+	if i != success {
+		return i
+	}
+
+	matches := make([]*models.Note, 0)
+	for _, n := range notes {
+		if strings.Contains(strings.ToLower(n.Text), strings.ToLower(query)) {
+			matches = append(matches, n)
+		}
+	}
+
+	if len(matches) == 0 {
+		c.UI.Output("No matches")
+		return success
+	}
+
+	c.UI.Output(fmt.Sprintf("Here are your notes matching '%s'", query))
+	c.printNotes(matches)
+
+	return c.promptChangeNote(matches)
+}
+
+// loadNotes queries all of the user's notes.
+func (c *NoteCommand) loadNotes() ([]*models.Note, int) {
+	q := c.DB.Query(models.NoteKind)
+	q.Select(data.AttrMap{
+		"owner_id": c.UserID,
+	})
+	iter, err := q.Execute()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error executing query: %s", err))
+		return nil, failure
+	}
+
+	n := models.NewNote()
+	notes := make([]*models.Note, 0)
+	for iter.Next(n) {
+		notes = append(notes, n)
+		n = models.NewNote()
+	}
+
+	if err := iter.Close(); err != nil {
+		c.UI.Error(fmt.Sprintf("Error executing query: %s", err))
+		return nil, failure
+	}
+
+	return notes, success
+}
+
+// printNotes prints the notes, numbered for promptChangeNote
+func (c *NoteCommand) printNotes(notes []*models.Note) {
+	for i := range notes {
+		c.UI.Output(fmt.Sprintf("-----------%d-------------", i))
+		c.UI.Output(notes[i].Text)
+	}
+}
+
+// promptChangeNote asks whether the user would like to delete or
+// edit one of the notes, and if so which one, and does so.
+func (c *NoteCommand) promptChangeNote(notes []*models.Note) int {
+	t, err := c.UI.Ask("Would you like to [D]elete or [E]dit any? (enter to continue)")
+	if err != nil {
+		return failure
+	}
+
+	var i int
+	if t != "" {
+		i, err = intInput(c.UI, "Which one?")
+		if err != nil {
+			return failure
+		}
+
+		if i < 0 || i >= len(notes) {
+			c.UI.Warn(fmt.Sprintf("Invalid note number: %d", i))
+			return failure
+		}
+	}
+
+	switch t {
+	case "d":
+		fallthrough
+	case "D":
+
+		err = c.DB.Delete(notes[i])
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error deleting the note: %s", err))
+			return failure
 		}
+	case "e":
+		fallthrough
+	case "E":
+		c.UI.Output(fmt.Sprintf("Current text is: %s", notes[i].Text))
+		text, err := c.UI.Ask("What would you like instead?:")
+		if err != nil {
+			return failure
+		}
+
+		notes[i].Text = text
+		notes[i].UpdatedAt = time.Now()
+		err = c.DB.Save(notes[i])
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error saving record: %s", err))
+			return failure
+		}
+	}
+
+	return success
+}
+
+// promptTagNote optionally tags the note with one of the user's tags,
+// reusing the tag command's selection prompt. The note is not saved.
+func (c *NoteCommand) promptTagNote(note *models.Note) int {
+	if tag, err := yesNo(c.UI, "Would you like to tag this note?"); err != nil {
+		c.UI.Error(fmt.Sprintf("Input error: %s", err))
+		return failure
+	} else if !tag {
+		return success
+	}
+
+	tc := &TagCommand{UI: c.UI, UserID: c.UserID, DB: c.DB}
+	if i := tc.init(); i != success {
+		return i
+	}
+
+	t, index := tc.promptSelectTag()
+	if index < 0 {
+		return failure
+	}
+
+	note.IncludeTag(t)
+	return success
+}
+
+// promptAttachNote optionally attaches the (saved) note to one of the
+// user's people, reusing the people command's selection prompt, so
+// that it shows up in 'elos people stream'.
+func (c *NoteCommand) promptAttachNote(note *models.Note) int {
+	if attach, err := yesNo(c.UI, "Would you like to attach this note to a person?"); err != nil {
+		c.UI.Error(fmt.Sprintf("Input error: %s", err))
+		return failure
+	} else if !attach {
+		return success
+	}
+
+	pc := &PeopleCommand{UI: c.UI, UserID: c.UserID, DB: c.DB}
+	if i := pc.init(); i != success {
+		return i
+	}
+
+	p, index := pc.promptSelectPerson()
+	if index < 0 {
+		return failure
+	}
+
+	p.IncludeNote(note)
+	if err := c.DB.Save(p); err != nil {
+		c.UI.Error(fmt.Sprintf("Error saving person: %s", err))
+		return failure
 	}
 
-	return 0
+	return success
 }
 
 func (c *NoteCommand) Synopsis() string {
@@ -0,0 +1,250 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/elos/data"
+	"github.com/elos/data/builtin/mem"
+	"github.com/elos/models"
+	"github.com/mitchellh/cli"
+)
+
+func newMockNoteCommand(t *testing.T) (*cli.MockUi, data.DB, *models.User, *NoteCommand) {
+	ui := new(cli.MockUi)
+	db := mem.NewDB()
+	user := newTestUser(t, db)
+
+	return ui, db, user, &NoteCommand{
+		UI:     ui,
+		UserID: user.ID().String(),
+		DB:     db,
+	}
+}
+
+// --- `elos note list` (invalid index) {{{
+func TestNoteListInvalidIndex(t *testing.T) {
+	ui, db, user, c := newMockNoteCommand(t)
+
+	note := newTestNote(t, db, user)
+	note.Text = "remember this"
+	if err := db.Save(note); err != nil {
+		t.Fatal(err)
+	}
+
+	// delete, the (nonexistent) note number 5
+	ui.InputReader = bytes.NewBufferString("d\n5\n")
+
+	t.Log("running: `elos note list`")
+	code := c.Run([]string{"list"})
+	t.Log("command `note list` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	if code == 0 {
+		t.Fatalf("Expected failure exit code")
+	}
+
+	if !strings.Contains(errput, "Invalid note number: 5") {
+		t.Fatalf("Error output should have warned of the invalid note number")
+	}
+
+	// verify the note was not deleted
+	if err := db.PopulateByID(note); err != nil {
+		t.Fatalf("Expected note to still exist: %s", err)
+	}
+}
+
+// --- }}}
+
+// --- `elos note new` (tagged and attached) {{{
+func TestNoteNewTaggedAndAttached(t *testing.T) {
+	ui, db, user, c := newMockNoteCommand(t)
+
+	tg := newTestTag(t, db, user)
+	tg.Name = "ideas"
+	if err := db.Save(tg); err != nil {
+		t.Fatal(err)
+	}
+
+	person := newTestPerson(t, db, user)
+
+	// text, yes tag it, the 0th tag, yes attach it, the 0th person
+	ui.InputReader = bytes.NewBufferString("remember\ny\n0\ny\n0\n")
+
+	t.Log("running: `elos note new`")
+	code := c.Run([]string{"new"})
+	t.Log("command `note new` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	if code != 0 {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	if err := db.PopulateByID(person); err != nil {
+		t.Fatal(err)
+	}
+
+	notes, err := person.Notes(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(notes) != 1 {
+		t.Fatalf("Expected the person to have 1 note, got %d", len(notes))
+	}
+
+	if got, want := notes[0].Text, "remember"; got != want {
+		t.Fatalf("note.Text: got %q, want %q", got, want)
+	}
+
+	tags, err := notes[0].Tags(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tags) != 1 || tags[0].Name != "ideas" {
+		t.Fatalf("Expected the note to be tagged 'ideas', got %v", tags)
+	}
+}
+
+// --- }}}
+
+// --- `elos note list` (delete error) {{{
+
+// failDeleteDB is a data.DB which fails to delete any record
+type failDeleteDB struct {
+	data.DB
+}
+
+func (db failDeleteDB) Delete(r data.Record) error {
+	return errors.New("delete failed")
+}
+
+func TestNoteListDeleteError(t *testing.T) {
+	ui, db, user, c := newMockNoteCommand(t)
+	c.DB = failDeleteDB{db}
+
+	note := newTestNote(t, db, user)
+	note.Text = "remember this"
+	if err := db.Save(note); err != nil {
+		t.Fatal(err)
+	}
+
+	// delete note number 0
+	ui.InputReader = bytes.NewBufferString("d\n0\n")
+
+	t.Log("running: `elos note list`")
+	code := c.Run([]string{"list"})
+	t.Log("command `note list` terminated")
+
+	errput := ui.ErrorWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", ui.OutputWriter.String())
+
+	if code != failure {
+		t.Fatalf("Expected exit code %d, got %d", failure, code)
+	}
+
+	if !strings.Contains(errput, "delete failed") {
+		t.Fatalf("Error output should have contained the cause, got: %s", errput)
+	}
+}
+
+// --- }}}
+
+// --- `elos note search` {{{
+func TestNoteSearch(t *testing.T) {
+	ui, db, user, c := newMockNoteCommand(t)
+
+	notes := make([]*models.Note, 0)
+	for _, text := range []string{"Buy MILK", "call mom", "milk the cow"} {
+		n := newTestNote(t, db, user)
+		n.Text = text
+		if err := db.Save(n); err != nil {
+			t.Fatal(err)
+		}
+		notes = append(notes, n)
+	}
+
+	// edit the first match
+	ui.InputReader = bytes.NewBufferString("e\n0\nedited\n")
+
+	t.Log("running: `elos note search milk`")
+	code := c.Run([]string{"search", "milk"})
+	t.Log("command `note search` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// the search is case-insensitive
+	if !strings.Contains(output, "Buy MILK") || !strings.Contains(output, "milk the cow") {
+		t.Fatal("Expected both notes mentioning milk to be listed")
+	}
+
+	if strings.Contains(output, "call mom") {
+		t.Fatal("Expected the note not mentioning milk not to be listed")
+	}
+
+	edited := 0
+	for _, n := range notes {
+		if err := db.PopulateByID(n); err != nil {
+			t.Fatal(err)
+		}
+
+		if n.Text == "edited" {
+			edited++
+		}
+	}
+
+	if edited != 1 {
+		t.Fatalf("Expected exactly one note to be edited, got %d", edited)
+	}
+
+	if notes[1].Text != "call mom" {
+		t.Fatal("Expected the note not matching not to be edited")
+	}
+}
+
+func TestNoteSearchNoMatches(t *testing.T) {
+	ui, db, user, c := newMockNoteCommand(t)
+
+	n := newTestNote(t, db, user)
+	n.Text = "call mom"
+	if err := db.Save(n); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := c.Run([]string{"search", "milk"}); code != success {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	if got, want := ui.OutputWriter.String(), "No matches\n"; got != want {
+		t.Fatalf("Expected %q, got %q", want, got)
+	}
+}
+
+// --- }}}
@@ -0,0 +1,109 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/mitchellh/cli"
+)
+
+// hasFlag reports whether the flag with the given name is among the
+// arguments, with either one or two dashes, i.e., -json or --json
+func hasFlag(args []string, name string) bool {
+	for _, a := range args {
+		if a == "-"+name || a == "--"+name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// outputJSON outputs the JSON encoding of v
+//
+// Use outputJSON to implement the --json flag of list subcommands,
+// which output the underlying models, without any decoration, so
+// that they can be consumed by scripts.
+func outputJSON(ui cli.Ui, v interface{}) error {
+	bytes, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	ui.Output(string(bytes))
+	return nil
+}
+
+// pageFlag parses the page size given with -page N or --page N,
+// a page size of 0, the default, means no paging
+func pageFlag(args []string) (int, error) {
+	return intFlag(args, "page", 0)
+}
+
+// intFlag parses the non-negative number given with the flag of the
+// given name, i.e., -next N or --next N. It returns def if the flag
+// isn't among the arguments.
+func intFlag(args []string, name string, def int) (int, error) {
+	for i, a := range args {
+		if a != "-"+name && a != "--"+name {
+			continue
+		}
+
+		if i+1 >= len(args) {
+			return 0, fmt.Errorf("%s requires a number", a)
+		}
+
+		n, err := strconv.Atoi(args[i+1])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("%s requires a non-negative number, got %q", a, args[i+1])
+		}
+
+		return n, nil
+	}
+
+	return def, nil
+}
+
+// stringFlag parses the value given with the flag of the given name,
+// i.e., -format json or --format json, returning def if the flag isn't
+// among the arguments. It also returns the arguments without the flag
+// and its value, for subcommands which take positional arguments.
+func stringFlag(args []string, name, def string) (string, []string, error) {
+	for i, a := range args {
+		if a != "-"+name && a != "--"+name {
+			continue
+		}
+
+		if i+1 >= len(args) {
+			return "", nil, fmt.Errorf("%s requires a value", a)
+		}
+
+		rest := make([]string, 0, len(args)-2)
+		rest = append(rest, args[:i]...)
+		rest = append(rest, args[i+2:]...)
+		return args[i+1], rest, nil
+	}
+
+	return def, args, nil
+}
+
+// page calls print for each index in [0, n), size indices at a time,
+// waiting for the user to press enter before each further page. A size
+// of 0 or less prints every index at once.
+//
+// It returns an error if the user's input can't be read, in which case
+// the remaining indices are not printed.
+func page(ui cli.Ui, n, size int, print func(i int)) error {
+	for i := 0; i < n; i++ {
+		if size > 0 && i > 0 && i%size == 0 {
+			if _, err := ui.Ask("more? [enter]"); err != nil {
+				return err
+			}
+		}
+
+		print(i)
+	}
+
+	return nil
+}
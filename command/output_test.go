@@ -0,0 +1,85 @@
+package command
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestPageFlag(t *testing.T) {
+	cases := map[string]struct {
+		args []string
+		want int
+		err  bool
+	}{
+		"absent":      {args: []string{"--json"}, want: 0},
+		"one dash":    {args: []string{"-page", "5"}, want: 5},
+		"two dashes":  {args: []string{"--page", "5"}, want: 5},
+		"missing":     {args: []string{"--page"}, err: true},
+		"not integer": {args: []string{"--page", "five"}, err: true},
+		"negative":    {args: []string{"--page", "-1"}, err: true},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			got, err := pageFlag(c.args)
+			if c.err {
+				if err == nil {
+					t.Fatalf("pageFlag(%v): expected an error", c.args)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("pageFlag(%v) error: %v", c.args, err)
+			}
+
+			if got != c.want {
+				t.Fatalf("pageFlag(%v): got %d, want %d", c.args, got, c.want)
+			}
+		})
+	}
+}
+
+// TestIntFlagError tests that the error of intFlag
+// names the flag, without assuming what it counts
+func TestIntFlagError(t *testing.T) {
+	_, err := intFlag([]string{"-days", "many"}, "days", 7)
+	if err == nil {
+		t.Fatal("intFlag: expected an error")
+	}
+
+	if got, want := err.Error(), `-days requires a non-negative number, got "many"`; got != want {
+		t.Fatalf("intFlag error: got %q, want %q", got, want)
+	}
+}
+
+func TestPage(t *testing.T) {
+	cases := map[string]struct {
+		n, size int
+		in      string
+		want    []int
+	}{
+		"no paging":   {n: 3, size: 0, want: []int{0, 1, 2}},
+		"all pages":   {n: 5, size: 2, in: "y\ny\n", want: []int{0, 1, 2, 3, 4}},
+		"first page":  {n: 5, size: 2, want: []int{0, 1}},
+		"exact pages": {n: 4, size: 2, in: "y\n", want: []int{0, 1, 2, 3}},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			ui := &cli.MockUi{InputReader: bytes.NewBufferString(c.in)}
+
+			got := make([]int, 0)
+			page(ui, c.n, c.size, func(i int) {
+				got = append(got, i)
+			})
+
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("page: got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
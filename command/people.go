@@ -1,6 +1,7 @@
 package command
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -30,6 +31,10 @@ type PeopleCommand struct {
 
 	// people is the list of this user's persons.
 	people []*models.Person
+
+	// pageSize is the number of people printPeopleList prints
+	// at a time, 0 prints them all at once
+	pageSize int
 }
 
 // Synopsis is a one-line, short summary of the 'people' command.
@@ -45,10 +50,14 @@ Usage:
 
 Subcommands:
 	delete	delete a person
-	list	list all of the people
+	edit	edit a person's name
+	list (--json|--page n)	list all of the people (as JSON, n at a time)
+	merge	merge a duplicate person into another
 	new	create a new person
-	note	add a note to a person
-	stream	stream notes for a person
+	note (-last)	add a note to a person (append to their latest note)
+	search	search people by name
+	show	show a person's contact details
+	stream (-desc)	stream notes for a person (newest first with -desc)
 `
 	return strings.TrimSpace(helpText)
 }
@@ -76,10 +85,18 @@ func (c *PeopleCommand) Run(args []string) int {
 		c.runList(args)
 	case "delete":
 		c.runDelete(args)
+	case "edit":
+		c.runEdit(args)
+	case "merge":
+		c.runMerge(args)
 	case "new":
 		c.runNew(args)
 	case "note":
 		c.runNote(args)
+	case "search":
+		c.runSearch(args)
+	case "show":
+		c.runShow(args)
 	case "stream":
 		c.runStream(args)
 	default:
@@ -88,6 +105,32 @@ func (c *PeopleCommand) Run(args []string) int {
 	return success
 }
 
+// contactNotePrefix is the first line of the note which holds a person's
+// contact details. The person model has no phone, email or birthday fields,
+// so they are kept in a structured note instead.
+const contactNotePrefix = "[contact]"
+
+// contactNoteText constructs the text of a contact note, skipping any
+// empty fields. It returns the empty string if all fields are empty.
+func contactNoteText(phone, email, birthday string) string {
+	lines := make([]string, 0, 3)
+	if phone != "" {
+		lines = append(lines, "Phone: "+phone)
+	}
+	if email != "" {
+		lines = append(lines, "Email: "+email)
+	}
+	if birthday != "" {
+		lines = append(lines, "Birthday: "+birthday)
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return contactNotePrefix + "\n" + strings.Join(lines, "\n")
+}
+
 // removePerson removes the person at the given index.
 // You may use this for removing a person after they have
 // been deleted
@@ -153,11 +196,27 @@ func (c *PeopleCommand) init() int {
 	return success
 }
 
-// printPeopleList prints a numbered list of the people in the people slice
-func (c *PeopleCommand) printPeopleList() {
+// printPeopleList prints a numbered list of the people in the people slice.
+// If selectors are provided, only the people which satisfy every selector
+// are printed, but the numbering still reflects their index in c.people.
+func (c *PeopleCommand) printPeopleList(selectors ...func(*models.Person) bool) {
+	indices := make([]int, 0, len(c.people))
+SelectLoop:
 	for i, p := range c.people {
-		c.printf("%d) %s %s", i, p.FirstName, p.LastName)
+		for i := range selectors {
+			if !selectors[i](p) {
+				continue SelectLoop
+			}
+		}
+
+		indices = append(indices, i)
 	}
+
+	// an input error just stops the paging early
+	page(c.UI, len(indices), c.pageSize, func(j int) {
+		p := c.people[indices[j]]
+		c.printf("%d) %s %s", indices[j], p.FirstName, p.LastName)
+	})
 }
 
 // promptSelectPerson prompts the user to select a person from their list
@@ -225,6 +284,28 @@ func (c *PeopleCommand) promptNewPerson() (*models.Person, int) {
 		return nil, failure
 	}
 
+	var phone, email, birthday string
+
+	if addDetails, err := yesNo(c.UI, "Would you like to add contact details?"); err != nil {
+		c.errorf("input error: %s", err)
+		return nil, failure
+	} else if addDetails {
+		if phone, inputErr = stringInput(c.UI, "Phone (blank to skip):"); inputErr != nil {
+			c.errorf("input error: %s", inputErr)
+			return nil, failure
+		}
+
+		if email, inputErr = stringInput(c.UI, "Email (blank to skip):"); inputErr != nil {
+			c.errorf("input error: %s", inputErr)
+			return nil, failure
+		}
+
+		if birthday, inputErr = stringInput(c.UI, "Birthday (blank to skip):"); inputErr != nil {
+			c.errorf("input error: %s", inputErr)
+			return nil, failure
+		}
+	}
+
 	p.OwnerId = c.UserID
 	p.UpdatedAt = time.Now()
 
@@ -233,6 +314,12 @@ func (c *PeopleCommand) promptNewPerson() (*models.Person, int) {
 		return nil, failure
 	}
 
+	if text := contactNoteText(phone, email, birthday); text != "" {
+		if _, out := c.addNote(p, text); out != success {
+			return nil, out
+		}
+	}
+
 	return p, success
 }
 
@@ -247,17 +334,25 @@ func (c *PeopleCommand) promptNewPerson() (*models.Person, int) {
 // a failure has occured the first return argument will be nil. The promptNewNote
 // function handles error message outputting itself.
 func (c *PeopleCommand) promptNewNote(p *models.Person) (*models.Note, int) {
-	n := models.NewNote()
-	n.SetID(c.DB.NewID())
-	n.CreatedAt = time.Now()
-
-	var inputErr error
-
-	if n.Text, inputErr = stringInput(c.UI, "Content"); inputErr != nil {
+	text, inputErr := stringInput(c.UI, "Content")
+	if inputErr != nil {
 		c.errorf("input err: %s", inputErr)
 		return nil, failure
 	}
 
+	return c.addNote(p, text)
+}
+
+// addNote creates and saves a new note with the given text, and links
+// it to the given person.
+//
+// It returns the new note and a status code, with the same semantics
+// as promptNewNote.
+func (c *PeopleCommand) addNote(p *models.Person, text string) (*models.Note, int) {
+	n := models.NewNote()
+	n.SetID(c.DB.NewID())
+	n.CreatedAt = time.Now()
+	n.Text = text
 	n.OwnerId = c.UserID
 	n.UpdatedAt = time.Now()
 
@@ -286,9 +381,11 @@ func (c *PeopleCommand) runDelete(args []string) int {
 	}
 
 	if confirm, err := yesNo(c.UI, fmt.Sprintf("Are you sure you want to delete %s %s", person.FirstName, person.LastName)); err != nil {
-		c.errorf(err.Error())
+		c.errorf("input error: %s", err)
+		return failure
 	} else if !confirm {
 		c.printf("Cancelled")
+		return success
 	}
 
 	if err := c.DB.Delete(person); err != nil {
@@ -302,20 +399,142 @@ func (c *PeopleCommand) runDelete(args []string) int {
 	return success
 }
 
+// runEdit runs the 'edit' subcommand with the given arguments.
+//
+// The 'edit' subcommand prompts the user for a person, and
+// then for which of their names to change.
+func (c *PeopleCommand) runEdit(args []string) int {
+	person, index := c.promptSelectPerson()
+	if index < 0 {
+		return failure
+	}
+
+	bytes, err := json.MarshalIndent(person, "", "	")
+	if err != nil {
+		c.errorf("%s", err)
+		return failure
+	}
+	c.printf(string(bytes))
+
+	var attributeToEdit string
+	if attributeToEdit, err = stringInput(c.UI, "Which attribute?"); err != nil {
+		c.errorf("input error: %s", err)
+		return failure
+	}
+
+	switch attributeToEdit {
+	case "first_name":
+		person.FirstName, err = stringInput(c.UI, "First Name:")
+	case "last_name":
+		person.LastName, err = stringInput(c.UI, "Last Name:")
+	default:
+		c.UI.Warn("That attribute is not recognized/supported")
+		return success
+	}
+
+	if err != nil {
+		c.errorf("input error: %s", err)
+		return failure
+	}
+
+	person.UpdatedAt = time.Now()
+
+	if err := c.DB.Save(person); err != nil {
+		c.errorf("error saving person: %s", err)
+		return failure
+	}
+
+	c.printf("Updated %s %s", person.FirstName, person.LastName)
+	return success
+}
+
 // runList runs the 'list' subcommand with the given arguments.
 //
 // The 'list' subcommand lists all the user's people.
 func (c *PeopleCommand) runList(args []string) int {
+	if hasFlag(args, "json") {
+		if err := outputJSON(c.UI, c.people); err != nil {
+			c.errorf("encoding people: %s", err)
+			return failure
+		}
+		return success
+	}
+
 	if len(c.people) == 0 {
 		c.printf("You have no people")
 		return success
 	}
 
+	size, err := pageFlag(args)
+	if err != nil {
+		c.errorf("%s", err)
+		return failure
+	}
+	c.pageSize = size
+
 	c.printf("Here are the people you have notes on:")
 	c.printPeopleList()
 	return success
 }
 
+// runMerge runs the 'merge' subcommand with the given arguments.
+//
+// The 'merge' subcommand prompts the user for a person to keep, and
+// a duplicate of them. The duplicate's notes are moved to the person
+// kept, and then the duplicate is deleted.
+func (c *PeopleCommand) runMerge(args []string) int {
+	c.printf("Which person should be kept?")
+	keep, keepIndex := c.promptSelectPerson()
+	if keepIndex < 0 {
+		return failure
+	}
+
+	c.printf("Which person is the duplicate, to merge into %s %s?", keep.FirstName, keep.LastName)
+	dup, dupIndex := c.promptSelectPerson()
+	if dupIndex < 0 {
+		return failure
+	}
+
+	if dupIndex == keepIndex {
+		c.UI.Warn("Can't merge a person into themselves")
+		return failure
+	}
+
+	if confirm, err := yesNo(c.UI, fmt.Sprintf("Are you sure you want to merge %s %s into %s %s", dup.FirstName, dup.LastName, keep.FirstName, keep.LastName)); err != nil {
+		c.errorf("input error: %s", err)
+		return failure
+	} else if !confirm {
+		c.printf("Cancelled")
+		return success
+	}
+
+	notes, err := dup.Notes(c.DB)
+	if err != nil {
+		c.errorf("error retrieving the notes: %s", err)
+		return failure
+	}
+
+	for _, n := range notes {
+		keep.IncludeNote(n)
+	}
+	keep.UpdatedAt = time.Now()
+
+	if err := c.DB.Save(keep); err != nil {
+		c.errorf("error saving person: %s", err)
+		return failure
+	}
+
+	if err := c.DB.Delete(dup); err != nil {
+		c.errorf("%s", err)
+		return failure
+	}
+
+	c.removePerson(dupIndex)
+	c.printf("Merged %d notes into %s %s", len(notes), keep.FirstName, keep.LastName)
+
+	return success
+}
+
 // runNew runs the 'new' subcommand with the given arguments.
 //
 // The 'new' subcommand provides prompts to create a new person.
@@ -338,6 +557,10 @@ func (c *PeopleCommand) runNote(args []string) int {
 		return failure
 	}
 
+	if hasFlag(args, "last") {
+		return c.appendNote(person)
+	}
+
 Adding:
 	for {
 		if _, out := c.promptNewNote(person); out != success {
@@ -357,14 +580,133 @@ Adding:
 	return success
 }
 
+// noteSeparator separates the text appended to a note from its
+// previous text
+const noteSeparator = "\n"
+
+// appendNote prompts for text, and appends it to the person's latest
+// note, or adds it as a new note if they have none. A contact note is
+// never appended to, as it is structured.
+func (c *PeopleCommand) appendNote(p *models.Person) int {
+	notes, err := p.Notes(c.DB)
+	if err != nil {
+		c.errorf("error retrieving the notes: %s", err)
+		return failure
+	}
+
+	sort.Sort(sort.Reverse(byCreatedAt(notes)))
+
+	var latest *models.Note
+	for _, n := range notes {
+		if !strings.HasPrefix(n.Text, contactNotePrefix) {
+			latest = n
+			break
+		}
+	}
+
+	if latest == nil {
+		if _, out := c.promptNewNote(p); out != success {
+			return out
+		}
+
+		c.printf("noted")
+		return success
+	}
+
+	c.printf("Latest note: %s", latest.Text)
+	text, err := stringInput(c.UI, "Content")
+	if err != nil {
+		c.errorf("input err: %s", err)
+		return failure
+	}
+
+	latest.Text += noteSeparator + text
+	latest.UpdatedAt = time.Now()
+
+	if err := c.DB.Save(latest); err != nil {
+		c.errorf("error saving note: %s", err)
+		return failure
+	}
+
+	c.printf("noted")
+	return success
+}
+
+// runSearch runs the 'search' subcommand with the given arguments.
+//
+// The 'search' subcommand lists the people whose first or last name
+// contains the query (case-insensitive). The numbering matches 'list',
+// so the indices can be used with 'note' and 'delete'.
+func (c *PeopleCommand) runSearch(args []string) int {
+	if len(args) < 2 {
+		c.printf("Usage: elos people search <query>")
+		return failure
+	}
+
+	query := strings.ToLower(strings.Join(args[1:], " "))
+	matches := func(p *models.Person) bool {
+		return strings.Contains(strings.ToLower(p.FirstName), query) ||
+			strings.Contains(strings.ToLower(p.LastName), query)
+	}
+
+	found := false
+	for _, p := range c.people {
+		if matches(p) {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		c.printf("No matching people")
+		return success
+	}
+
+	c.printPeopleList(matches)
+	return success
+}
+
+// runShow runs the 'show' subcommand with the given arguments.
+//
+// The 'show' subcommand prints a person's name, along with any
+// contact details recorded when they were created.
+func (c *PeopleCommand) runShow(args []string) int {
+	person, index := c.promptSelectPerson()
+	if index < 0 {
+		return failure
+	}
+
+	notes, err := person.Notes(c.DB)
+	if err != nil {
+		c.errorf("error retrieving the notes: %s", err)
+		return failure
+	}
+
+	c.printf("%s %s", person.FirstName, person.LastName)
+	for _, n := range notes {
+		if strings.HasPrefix(n.Text, contactNotePrefix) {
+			for _, line := range strings.Split(strings.TrimPrefix(n.Text, contactNotePrefix), "\n") {
+				if line != "" {
+					c.printf("\t%s", line)
+				}
+			}
+		}
+	}
+	c.printf("\t%d notes", len(notes))
+
+	return success
+}
+
 // runStream runs the stream command with the given arguments.
 //
 // The stream command loads all the note on a particular user,
 // and allows you to scroll through them by pressing enter.
 //
-// Interestingly, the current design is that oldest notes come first
-// but perhaps this may change.
+// Oldest notes come first, unless the -desc flag is provided,
+// in which case the newest notes come first.
 func (c *PeopleCommand) runStream(args []string) int {
+	desc := len(args) == 2 && args[1] == "-desc"
+
 	person, index := c.promptSelectPerson()
 	if index < 0 {
 		return failure
@@ -378,7 +720,11 @@ func (c *PeopleCommand) runStream(args []string) int {
 	}
 
 	// sort the notes
-	sort.Sort(byCreatedAt(notes))
+	if desc {
+		sort.Sort(sort.Reverse(byCreatedAt(notes)))
+	} else {
+		sort.Sort(byCreatedAt(notes))
+	}
 
 	c.printf("press enter to scroll through")
 	for i, n := range notes {
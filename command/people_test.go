@@ -2,6 +2,7 @@ package command
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
@@ -164,6 +165,109 @@ func TestPeopleDelete(t *testing.T) {
 	}
 }
 
+func TestPeopleDeleteCancelled(t *testing.T) {
+	ui, db, user, c := newMockPeopleCommand(t)
+
+	t.Log("Creating a test person")
+	// load the person
+	person := newTestPerson(t, db, user)
+	person.FirstName = "Jack"
+	person.LastName = "Frost"
+	if err := db.Save(person); err != nil {
+		t.Fatal(err)
+	}
+	t.Log("Created")
+
+	// select the first person, but don't confirm
+	ui.InputReader = bytes.NewBufferString("0\nn\n")
+
+	t.Log("running: `elos people delete`")
+	code := c.Run([]string{"delete"})
+	t.Log("command `delete` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// verify the cancellation was acknowledged
+	if !strings.Contains(output, "Cancelled") {
+		t.Fatalf("Output should have contained 'Cancelled'")
+	}
+
+	// verify that the person still exists
+	if err := db.PopulateByID(person); err != nil {
+		t.Fatalf("expected the person to still exist, got: %s", err)
+	}
+}
+
+// --- }}}
+
+// --- `elos people edit` {{{
+func TestPeopleEdit(t *testing.T) {
+	ui, db, user, c := newMockPeopleCommand(t)
+
+	t.Log("Creating a test person")
+	// load the person
+	person := newTestPerson(t, db, user)
+	person.FirstName = "Jack"
+	person.LastName = "Frost"
+	if err := db.Save(person); err != nil {
+		t.Fatal(err)
+	}
+	t.Log("Created")
+
+	input := strings.Join([]string{
+		"0",         // selecting the person
+		"last_name", // the attribute to edit
+		"Sprat",     // the new last name
+	}, "\n")
+
+	ui.InputReader = bytes.NewBufferString(input)
+
+	t.Log("running: `elos people edit`")
+	code := c.Run([]string{"edit"})
+	t.Log("command `edit` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// verify the name was changed
+	if err := db.PopulateByID(person); err != nil {
+		t.Fatalf("Error retrieving person: %s", err)
+	}
+
+	if person.LastName != "Sprat" {
+		t.Fatalf("Last name should have been Sprat, not '%s'", person.LastName)
+	}
+
+	if person.FirstName != "Jack" {
+		t.Fatalf("First name should have stayed Jack, not '%s'", person.FirstName)
+	}
+}
+
 // --- }}}
 
 // --- `elos people list` {{{
@@ -210,6 +314,121 @@ func TestPeopleList(t *testing.T) {
 	}
 }
 
+// TestPeopleListJSON tests the `list --json` subcommand
+func TestPeopleListJSON(t *testing.T) {
+	ui, db, user, c := newMockPeopleCommand(t)
+
+	person := newTestPerson(t, db, user)
+	person.FirstName = "Nick"
+	if err := db.Save(person); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("running: `elos people list --json`")
+	code := c.Run([]string{"list", "--json"})
+	t.Log("command `list --json` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	var people []struct {
+		FirstName string `json:"first_name"`
+	}
+	if err := json.Unmarshal([]byte(output), &people); err != nil {
+		t.Fatalf("Output should be valid JSON: %s", err)
+	}
+
+	if len(people) != 1 || people[0].FirstName != "Nick" {
+		t.Fatalf("Expected the JSON to contain the person 'Nick', got: %+v", people)
+	}
+}
+
+// --- }}}
+
+// --- `elos people merge` {{{
+func TestPeopleMerge(t *testing.T) {
+	ui, db, user, c := newMockPeopleCommand(t)
+
+	people := make([]*models.Person, 2)
+	for i, text := range []string{"is_a_nice_person", "is_a_good_person"} {
+		people[i] = newTestPerson(t, db, user)
+		people[i].FirstName = "Jack"
+		people[i].LastName = "Frost"
+
+		n := newTestNote(t, db, user)
+		n.Text = text
+		if err := db.Save(n); err != nil {
+			t.Fatal(err)
+		}
+
+		people[i].IncludeNote(n)
+		if err := db.Save(people[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	input := strings.Join([]string{
+		"0", // the person to keep
+		"1", // the duplicate
+		"y", // confirm
+	}, "\n")
+	ui.InputReader = bytes.NewBufferString(input)
+
+	t.Log("running: `elos people merge`")
+	code := c.Run([]string{"merge"})
+	t.Log("command `merge` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// exactly one of the two remains, with both notes
+	var survivors []*models.Person
+	for _, p := range people {
+		switch err := db.PopulateByID(p); err {
+		case nil:
+			survivors = append(survivors, p)
+		case data.ErrNotFound:
+		default:
+			t.Fatal(err)
+		}
+	}
+
+	if len(survivors) != 1 {
+		t.Fatalf("Expected exactly one person to remain, %d did", len(survivors))
+	}
+
+	notes, err := survivors[0].Notes(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(notes) != 2 {
+		t.Fatalf("The remaining person should have exactly 2 notes, has %d", len(notes))
+	}
+}
+
 // --- }}}
 
 // --- `elos people new` {{{
@@ -219,6 +438,7 @@ func TestPeopleNew(t *testing.T) {
 	input := strings.Join([]string{
 		"Nick",     // First Name
 		"Landolfi", // Last Name
+		"n",        // no contact details
 	}, "\n")
 
 	ui.InputReader = bytes.NewBufferString(input)
@@ -258,6 +478,62 @@ func TestPeopleNew(t *testing.T) {
 	}
 }
 
+func TestPeopleNewWithPhone(t *testing.T) {
+	ui, db, _, c := newMockPeopleCommand(t)
+
+	input := strings.Join([]string{
+		"Nick",             // First Name
+		"Landolfi",         // Last Name
+		"y",                // add contact details
+		"555-0100",         // Phone
+		"nick@example.com", // Email
+		"1995-01-01",       // Birthday
+	}, "\n")
+
+	ui.InputReader = bytes.NewBufferString(input)
+
+	t.Log("running: `elos people new`")
+	code := c.Run([]string{"new"})
+	t.Log("command `new` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	person := models.NewPerson()
+	if err := db.PopulateByField("first_name", "Nick", person); err != nil {
+		t.Fatalf("Error looking for the new person: %s", err)
+	}
+
+	notes, err := person.Notes(db)
+	if err != nil {
+		t.Fatalf("Error retrieving the notes on the new person: %s", err)
+	}
+
+	if len(notes) != 1 {
+		t.Fatalf("The person should have exactly 1 note, got %d", len(notes))
+	}
+
+	if !strings.HasPrefix(notes[0].Text, contactNotePrefix) {
+		t.Fatalf("The note should be a contact note, got: %s", notes[0].Text)
+	}
+
+	if !strings.Contains(notes[0].Text, "Phone: 555-0100") {
+		t.Fatalf("The contact note should contain the phone number, got: %s", notes[0].Text)
+	}
+}
+
 // --- }}}
 
 // --- `elos people note` {{{
@@ -347,6 +623,132 @@ func TestPeopleNote(t *testing.T) {
 	}
 }
 
+// TestPeopleNoteLast tests the `note -last` subcommand,
+// which appends to the latest note
+func TestPeopleNoteLast(t *testing.T) {
+	ui, db, user, c := newMockPeopleCommand(t)
+
+	person := newTestPerson(t, db, user)
+	person.FirstName = "Jack"
+	person.LastName = "Frost"
+
+	older := newTestNote(t, db, user)
+	older.Text = "met_at_the_park"
+	older.CreatedAt = time.Now().Add(-time.Hour)
+	latest := newTestNote(t, db, user)
+	latest.Text = "is_a_nice_person"
+	for _, n := range []*models.Note{older, latest} {
+		if err := db.Save(n); err != nil {
+			t.Fatal(err)
+		}
+		person.IncludeNote(n)
+	}
+	if err := db.Save(person); err != nil {
+		t.Fatal(err)
+	}
+
+	input := strings.Join([]string{
+		"0",                // selecting the person
+		"is_a_good_person", // the appended text
+	}, "\n")
+	ui.InputReader = bytes.NewBufferString(input)
+
+	t.Log("running: `elos people note -last`")
+	code := c.Run([]string{"note", "-last"})
+	t.Log("command `note -last` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	if err := db.PopulateByID(latest); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := latest.Text, "is_a_nice_person\nis_a_good_person"; got != want {
+		t.Fatalf("latest note: got %q, want %q", got, want)
+	}
+
+	if err := db.PopulateByID(older); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := older.Text, "met_at_the_park"; got != want {
+		t.Fatalf("older note: got %q, want %q", got, want)
+	}
+
+	notes, err := person.Notes(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(notes) != 2 {
+		t.Fatalf("The person should still have exactly 2 notes, has %d", len(notes))
+	}
+}
+
+// --- }}}
+
+// --- `elos people search` {{{
+func TestPeopleSearch(t *testing.T) {
+	ui, db, user, c := newMockPeopleCommand(t)
+
+	t.Log("Creating test people")
+	jack := newTestPerson(t, db, user)
+	jack.FirstName = "Jack"
+	jack.LastName = "Frost"
+	if err := db.Save(jack); err != nil {
+		t.Fatal(err)
+	}
+	jill := newTestPerson(t, db, user)
+	jill.FirstName = "Jill"
+	jill.LastName = "Smith"
+	if err := db.Save(jill); err != nil {
+		t.Fatal(err)
+	}
+	t.Log("Created")
+
+	t.Log("running: `elos people search smith`")
+	code := c.Run([]string{"search", "smith"})
+	t.Log("command `search` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// verify Jill was listed
+	if !strings.Contains(output, "Jill") {
+		t.Fatalf("Output should have contained the matching person's name")
+	}
+
+	// verify Jack was not
+	if strings.Contains(output, "Jack") {
+		t.Fatalf("Output should not have contained the non-matching person's name")
+	}
+}
+
 // --- }}}
 
 // --- `elos people stream` {{{
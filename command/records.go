@@ -1,10 +1,15 @@
 package command
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/elos/x/data"
 	models "github.com/elos/x/models/proto"
@@ -29,10 +34,12 @@ Usage:
 	elos records <subcommand>
 
 Subcommands:
-	kinds	    list known kinds
-	count       count records
-	query		create a query
-	changes		listen for changes
+	kinds [--counts]	list known kinds (with the number of records of each)
+	count [kind [field=value...]]	count records, filtered by field equality
+	query [-format json|table] [kind [field=value...]]	query records, filtered by field equality
+	changes [kind [field=value...]]	listen for changes, filtered by field equality
+	get [kind [id]]	print the record of a kind with the id
+	delete [kind [id]]	delete the record of a kind with the id, if it is yours
 `
 	return strings.TrimSpace(helpText)
 }
@@ -45,48 +52,167 @@ func (c *RecordsCommand) Run(args []string) int {
 
 	switch args[0] {
 	case "kinds":
-		return c.runKinds()
+		return c.runKinds(args[1:])
 	case "count":
-		return c.runCount()
+		return c.runCount(args[1:])
 	case "query":
-		return c.runQuery()
+		return c.runQuery(args[1:])
 	case "changes":
-		return c.runChanges()
+		return c.runChanges(args[1:])
+	case "get":
+		return c.runGet(args[1:])
+	case "delete":
+		return c.runDelete(args[1:])
 	}
 
 	c.UI.Output(c.Help())
 	return success
 }
 
-var kinds string
+// kindsList is the list of known kinds, as printed by 'elos records kinds'
+var kindsList string
 
 func init() {
 	s := make([]string, len(models.Kinds))
 	for i, k := range models.Kinds {
 		s[i] = "* " + k.String()
 	}
-	kinds = strings.Join(s, "\n")
+	kindsList = strings.Join(s, "\n")
 }
 
-func (c *RecordsCommand) runKinds() int {
-	c.UI.Output(kinds)
+// runKinds runs the 'kinds' subcommand, which lists the known kinds.
+// With --counts it also counts the records of each kind, a kind which
+// can't be counted is listed as an error rather than ending the listing.
+func (c *RecordsCommand) runKinds(args []string) int {
+	if !hasFlag(args, "counts") {
+		c.UI.Output(kindsList)
+		return success
+	}
+
+	lines := make([]string, len(models.Kinds))
+	for i, k := range models.Kinds {
+		n, err := c.count(&data.Query{Kind: k})
+		if err != nil {
+			lines[i] = fmt.Sprintf("* %s: error", k)
+			continue
+		}
+
+		lines[i] = fmt.Sprintf("* %s: %d", k, n)
+	}
+
+	c.UI.Output(strings.Join(lines, "\n"))
 	return success
 }
 
-func (c *RecordsCommand) runCount() int {
-	k, err := stringInput(c.UI, "Which kind?")
+// errorf is a IO function which performs the equivalent of log.Errorf
+// in the standard lib, except using the cli.Ui interface with which
+// the RecordsCommand was provided.
+func (c *RecordsCommand) errorf(s string, values ...interface{}) {
+	c.UI.Error("[elos records] Error: " + fmt.Sprintf(s, values...))
+}
+
+// parseKind parses the name of a kind, case-insensitively.
+func parseKind(k string) (models.Kind, error) {
+	kind, ok := models.Kind_value[strings.ToUpper(k)]
+	if !ok {
+		return 0, fmt.Errorf("unknown kind %q, see 'elos records kinds'", k)
+	}
+
+	return models.Kind(kind), nil
+}
+
+// parseFilters parses arguments of the form field=value into
+// equality filters.
+func parseFilters(args []string) ([]*data.Filter, error) {
+	filters := make([]*data.Filter, len(args))
+	for i, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid filter %q, expected field=value", arg)
+		}
+
+		filters[i] = &data.Filter{
+			Op:    data.Filter_EQ,
+			Field: parts[0],
+			Reference: &models.Value{
+				Type:    models.Value_STRING,
+				String_: parts[1],
+			},
+		}
+	}
+
+	return filters, nil
+}
+
+// kind retrieves the kind to act on, from the first argument if there is one,
+// otherwise by prompting for it.
+func (c *RecordsCommand) kind(args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+
+	return stringInput(c.UI, "Which kind?")
+}
+
+// query constructs the query described by the arguments: the kind,
+// prompted for if not given, followed by any field=value filters.
+//
+// It returns the query and a status code, the query is only valid
+// if the status is success. query handles printing errors.
+func (c *RecordsCommand) query(args []string) (*data.Query, int) {
+	k, err := c.kind(args)
 	if err != nil {
-		return failure
+		return nil, failure
 	}
 
-	results, err := c.DBClient.Query(context.Background(),
-		&data.Query{
-			Kind: models.Kind(models.Kind_value[strings.ToUpper(k)]),
-		})
+	kind, err := parseKind(k)
 	if err != nil {
+		c.errorf("%s", err)
+		return nil, failure
+	}
+
+	q := &data.Query{
+		Kind: kind,
+	}
+
+	if len(args) > 1 {
+		if q.Filters, err = parseFilters(args[1:]); err != nil {
+			c.errorf("%s", err)
+			return nil, failure
+		}
+	}
+
+	return q, success
+}
+
+// runCount runs the 'count' subcommand, which prints the number of
+// records of a kind. If the kind is given as an argument there is no
+// prompt, so the output is only the number. Any further arguments of
+// the form field=value filter the records counted, as with 'query'.
+func (c *RecordsCommand) runCount(args []string) int {
+	q, code := c.query(args)
+	if code != success {
+		return code
+	}
+
+	n, err := c.count(q)
+	if err != nil {
+		c.errorf("querying: %s", err)
 		return failure
 	}
 
+	c.UI.Output(fmt.Sprintf("%d", n))
+
+	return success
+}
+
+// count counts the records matching the query.
+func (c *RecordsCommand) count(q *data.Query) (int, error) {
+	results, err := c.DBClient.Query(context.Background(), q)
+	if err != nil {
+		return 0, err
+	}
+
 	n := 0
 
 	for {
@@ -95,32 +221,48 @@ func (c *RecordsCommand) runCount() int {
 			break
 		}
 		if err != nil {
-			return failure
+			return 0, err
 		}
 
 		n++
 	}
 
-	c.UI.Output(fmt.Sprintf("%d", n))
-
-	return success
+	return n, nil
 }
 
-func (c *RecordsCommand) runQuery() int {
-	k, err := stringInput(c.UI, "Which kind?")
+// runQuery runs the 'query' subcommand, which prints the records of a
+// kind. Any further arguments of the form field=value filter the records
+// to those whose field equals the value, all filters must match.
+//
+// With -format json each record is printed as JSON, otherwise the records
+// are printed as a table of the fields common to all models.
+func (c *RecordsCommand) runQuery(args []string) int {
+	format, args, err := stringFlag(args, "format", "table")
 	if err != nil {
+		c.errorf("%s", err)
 		return failure
 	}
 
-	results, err := c.DBClient.Query(context.Background(),
-		&data.Query{
-			Kind: models.Kind(models.Kind_value[strings.ToUpper(k)]),
-		})
+	if format != "json" && format != "table" {
+		c.errorf("unknown format %q, expected json or table", format)
+		return failure
+	}
+
+	q, code := c.query(args)
+	if code != success {
+		return code
+	}
+
+	results, err := c.DBClient.Query(context.Background(), q)
 	if err != nil {
+		c.errorf("querying: %s", err)
 		return failure
 	}
 
 	n := 0
+	table := new(bytes.Buffer)
+	w := tabwriter.NewWriter(table, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tOWNER_ID\tCREATED_AT")
 
 	for {
 		r, err := results.Recv()
@@ -130,9 +272,32 @@ func (c *RecordsCommand) runQuery() int {
 		if err != nil {
 			return failure
 		}
-		c.UI.Output(fmt.Sprintf("%v", r))
 
 		n++
+
+		if format == "json" {
+			var v interface{} = r
+			if m := recordModel(r); m.IsValid() {
+				v = m.Interface()
+			}
+
+			b, err := json.Marshal(v)
+			if err != nil {
+				c.errorf("encoding record: %s", err)
+				return failure
+			}
+
+			c.UI.Output(string(b))
+			continue
+		}
+
+		id, ownerID, createdAt := recordColumns(r)
+		fmt.Fprintf(w, "%s\t%s\t%s\n", id, ownerID, createdAt)
+	}
+
+	if format == "table" && n > 0 {
+		w.Flush()
+		c.UI.Output(strings.TrimSuffix(table.String(), "\n"))
 	}
 
 	c.UI.Output(fmt.Sprintf("%d results", n))
@@ -140,17 +305,146 @@ func (c *RecordsCommand) runQuery() int {
 	return success
 }
 
-func (c *RecordsCommand) runChanges() int {
-	k, err := stringInput(c.UI, "Which kind?")
+// get retrieves the record of the kind with the id. It returns
+// a nil record if there is no such record.
+func (c *RecordsCommand) get(ctx context.Context, kind models.Kind, id string) (*data.Record, error) {
+	filters, err := parseFilters([]string{"id=" + id})
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := c.DBClient.Query(ctx, &data.Query{
+		Kind:    kind,
+		Filters: filters,
+	})
 	if err != nil {
+		return nil, err
+	}
+
+	rec, err := results.Recv()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if err := results.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	if err == io.EOF {
+		return nil, nil
+	}
+
+	return rec, nil
+}
+
+// record retrieves the record described by the arguments: the kind and
+// then the id, either of which is prompted for if not given.
+//
+// It returns the record and a status code, the record is only valid if
+// the status is success. A record which doesn't exist is a failure.
+// record handles printing errors.
+func (c *RecordsCommand) record(args []string) (*data.Record, int) {
+	k, err := c.kind(args)
+	if err != nil {
+		return nil, failure
+	}
+
+	kind, err := parseKind(k)
+	if err != nil {
+		c.errorf("%s", err)
+		return nil, failure
+	}
+
+	var id string
+	if len(args) > 1 {
+		id = args[1]
+	} else if id, err = stringInput(c.UI, "Which id?"); err != nil {
+		return nil, failure
+	}
+
+	rec, err := c.get(context.Background(), kind, id)
+	if err != nil {
+		c.errorf("querying: %s", err)
+		return nil, failure
+	}
+
+	if rec == nil {
+		c.errorf("no %s with id %q", kind, id)
+		return nil, failure
+	}
+
+	return rec, success
+}
+
+// runGet runs the 'get' subcommand, which prints the record
+// of a kind with an id.
+func (c *RecordsCommand) runGet(args []string) int {
+	rec, code := c.record(args)
+	if code != success {
+		return code
+	}
+
+	var v interface{} = rec
+	if m := recordModel(rec); m.IsValid() {
+		v = m.Interface()
+	}
+
+	if err := outputJSON(c.UI, v); err != nil {
+		c.errorf("encoding record: %s", err)
+		return failure
+	}
+
+	return success
+}
+
+// runDelete runs the 'delete' subcommand, which deletes the record of
+// a kind with an id, after confirmation. Only records owned by the
+// current user may be deleted.
+func (c *RecordsCommand) runDelete(args []string) int {
+	rec, code := c.record(args)
+	if code != success {
+		return code
+	}
+
+	id, owner, _ := recordColumns(rec)
+	if owner != c.UserID {
+		c.errorf("%s %q is not owned by you", rec.Kind, id)
+		return failure
+	}
+
+	if confirm, err := yesNo(c.UI, fmt.Sprintf("Delete %s %s?", rec.Kind, id)); err != nil {
+		c.errorf("input error: %s", err)
+		return failure
+	} else if !confirm {
+		c.UI.Output("Cancelled")
+		return success
+	}
+
+	if _, err := c.DBClient.Mutate(context.Background(), &data.Mutation{
+		Op:     data.Mutation_DELETE,
+		Record: rec,
+	}); err != nil {
+		c.errorf("deleting: %s", err)
 		return failure
 	}
 
-	results, err := c.DBClient.Changes(context.Background(),
-		&data.Query{
-			Kind: models.Kind(models.Kind_value[strings.ToUpper(k)]),
-		})
+	c.UI.Output(fmt.Sprintf("Deleted %s %s", rec.Kind, id))
+	return success
+}
+
+// runChanges runs the 'changes' subcommand, which prints the changes to
+// records of a kind as they happen, until the server closes the stream.
+// Any further arguments of the form field=value filter the changes, for
+// example owner_id=<id> to watch a single user's activity.
+func (c *RecordsCommand) runChanges(args []string) int {
+	q, code := c.query(args)
+	if code != success {
+		return code
+	}
+
+	results, err := c.DBClient.Changes(context.Background(), q)
 	if err != nil {
+		c.errorf("listening for changes: %s", err)
 		return failure
 	}
 
@@ -160,12 +454,92 @@ func (c *RecordsCommand) runChanges() int {
 			break
 		}
 		if err != nil {
+			c.errorf("receiving change: %s", err)
 			return failure
 		}
-		c.UI.Output(fmt.Sprintf("%v", r))
+		c.UI.Output(formatChange(r))
 	}
 
 	c.UI.Output("stream closed by server")
 
 	return success
 }
+
+// formatChange formats a change as a single line of the mutation op,
+// the kind, and the id and name (if it has one) of the record, i.e.,
+// `UPDATE TASK 5 "write the report"`.
+func formatChange(change *data.Change) string {
+	line := change.Op.String()
+	if change.Record == nil {
+		return line
+	}
+
+	line += " " + change.Record.Kind.String()
+
+	id, name := recordSummary(change.Record)
+	if id != "" {
+		line += " " + id
+	}
+	if name != "" {
+		line += fmt.Sprintf(" %q", name)
+	}
+
+	return line
+}
+
+// recordModel retrieves the model held by a record. A record holds its
+// model in the one non-nil field of its kind. It returns the invalid
+// reflect.Value if the record holds no model.
+func recordModel(r *data.Record) reflect.Value {
+	v := reflect.Indirect(reflect.ValueOf(r))
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if f.Kind() != reflect.Ptr || f.IsNil() || f.Elem().Kind() != reflect.Struct {
+			continue
+		}
+
+		return f
+	}
+
+	return reflect.Value{}
+}
+
+// stringField retrieves the string field of the given name, if the
+// model has one.
+func stringField(m reflect.Value, name string) string {
+	if f := m.FieldByName(name); f.IsValid() && f.Kind() == reflect.String {
+		return f.String()
+	}
+
+	return ""
+}
+
+// recordSummary retrieves the id and name, if any, of the model held by a
+// record.
+func recordSummary(r *data.Record) (id, name string) {
+	m := recordModel(r)
+	if !m.IsValid() {
+		return
+	}
+
+	return stringField(m.Elem(), "Id"), stringField(m.Elem(), "Name")
+}
+
+// recordColumns retrieves the fields common to all models of the model
+// held by a record, for the table format of 'query'.
+func recordColumns(r *data.Record) (id, ownerID, createdAt string) {
+	m := recordModel(r)
+	if !m.IsValid() {
+		return
+	}
+
+	id, ownerID = stringField(m.Elem(), "Id"), stringField(m.Elem(), "OwnerId")
+
+	if f := m.Elem().FieldByName("CreatedAt"); f.IsValid() {
+		if ts, ok := f.Interface().(*models.Timestamp); ok && ts != nil {
+			createdAt = ts.Time().Format(time.RFC3339)
+		}
+	}
+
+	return
+}
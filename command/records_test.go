@@ -28,6 +28,80 @@ func apply(ss []string, f func(s string) string) []string {
 	return ss
 }
 
+// countState constructs the prior state of the `elos records count` cases
+func countState() data.State {
+	return data.State{
+		models.Kind_USER: []*data.Record{
+			&data.Record{
+				Kind: models.Kind_USER,
+				User: &models.User{
+					Id: "1",
+				},
+			},
+			&data.Record{
+				Kind: models.Kind_USER,
+				User: &models.User{
+					Id: "2",
+				},
+			},
+		},
+		models.Kind_CREDENTIAL: []*data.Record{
+			&data.Record{
+				Kind: models.Kind_CREDENTIAL,
+				Credential: &models.Credential{
+					Id:      "3",
+					Type:    models.Credential_PASSWORD,
+					Public:  "pu",
+					Private: "pr",
+					OwnerId: "1",
+				},
+			},
+			&data.Record{
+				Kind: models.Kind_CREDENTIAL,
+				Credential: &models.Credential{
+					Id:      "4",
+					Type:    models.Credential_PASSWORD,
+					Public:  "2pu",
+					Private: "pr",
+					OwnerId: "2",
+				},
+			},
+		},
+		models.Kind_SESSION: []*data.Record{
+			&data.Record{
+				Kind: models.Kind_SESSION,
+				Session: &models.Session{
+					Id:           "5",
+					AccessToken:  "non-empty",
+					ExpiresAt:    models.TimestampFrom(time.Now().Add(5 * time.Minute)).WithoutNanos(),
+					OwnerId:      "1",
+					CredentialId: "3",
+				},
+			},
+			&data.Record{
+				Kind: models.Kind_SESSION,
+				Session: &models.Session{
+					Id:           "4",
+					AccessToken:  "non-empty",
+					ExpiresAt:    models.TimestampFrom(time.Now().Add(5 * time.Minute)).WithoutNanos(),
+					OwnerId:      "1",
+					CredentialId: "3",
+				},
+			},
+			&data.Record{
+				Kind: models.Kind_SESSION,
+				Session: &models.Session{
+					Id:           "4",
+					AccessToken:  "non-empty",
+					ExpiresAt:    models.TimestampFrom(time.Now().Add(5 * time.Minute)).WithoutNanos(),
+					OwnerId:      "2",
+					CredentialId: "4",
+				},
+			},
+		},
+	}
+}
+
 func TestRecords(t *testing.T) {
 	cases := map[string]struct {
 		args             []string
@@ -63,87 +137,110 @@ func TestRecords(t *testing.T) {
 				},
 			},
 		},
+		"elos records kinds --counts": {
+			args: []string{"kinds", "--counts"},
+			out: []byte(strings.Join(apply(s(models.Kinds), func(s string) string {
+				switch s {
+				case "USER", "CREDENTIAL", "SESSION":
+					return "* " + s + ": 2"
+				default:
+					return "* " + s + ": 0"
+				}
+			}), "\n") + "\n"),
+			prior: countState(),
+		},
 		// }}}
 
 		// elos records count {{{
 		"elos records count": {
-			args: []string{"count"},
-			in:   []byte("SESSION\n"),
-			out:  []byte("Which kind? [string]:2\n"),
-			prior: data.State{
-				models.Kind_USER: []*data.Record{
-					&data.Record{
-						Kind: models.Kind_USER,
-						User: &models.User{
-							Id: "1",
-						},
-					},
-					&data.Record{
-						Kind: models.Kind_USER,
-						User: &models.User{
-							Id: "2",
-						},
-					},
-				},
-				models.Kind_CREDENTIAL: []*data.Record{
-					&data.Record{
-						Kind: models.Kind_CREDENTIAL,
-						Credential: &models.Credential{
-							Id:      "3",
-							Type:    models.Credential_PASSWORD,
-							Public:  "pu",
-							Private: "pr",
-							OwnerId: "1",
-						},
-					},
-					&data.Record{
-						Kind: models.Kind_CREDENTIAL,
-						Credential: &models.Credential{
-							Id:      "4",
-							Type:    models.Credential_PASSWORD,
-							Public:  "2pu",
-							Private: "pr",
-							OwnerId: "2",
-						},
-					},
-				},
-				models.Kind_SESSION: []*data.Record{
-					&data.Record{
-						Kind: models.Kind_SESSION,
-						Session: &models.Session{
-							Id:           "5",
-							AccessToken:  "non-empty",
-							ExpiresAt:    models.TimestampFrom(time.Now().Add(5 * time.Minute)).WithoutNanos(),
-							OwnerId:      "1",
-							CredentialId: "3",
-						},
-					},
-					&data.Record{
-						Kind: models.Kind_SESSION,
-						Session: &models.Session{
-							Id:           "4",
-							AccessToken:  "non-empty",
-							ExpiresAt:    models.TimestampFrom(time.Now().Add(5 * time.Minute)).WithoutNanos(),
-							OwnerId:      "1",
-							CredentialId: "3",
-						},
-					},
-					&data.Record{
-						Kind: models.Kind_SESSION,
-						Session: &models.Session{
-							Id:           "4",
-							AccessToken:  "non-empty",
-							ExpiresAt:    models.TimestampFrom(time.Now().Add(5 * time.Minute)).WithoutNanos(),
-							OwnerId:      "2",
-							CredentialId: "4",
-						},
-					},
-				},
-			},
+			args:  []string{"count"},
+			in:    []byte("SESSION\n"),
+			out:   []byte("Which kind? [string]:2\n"),
+			prior: countState(),
+		},
+		"elos records count SESSION": {
+			args:  []string{"count", "SESSION"},
+			out:   []byte("2\n"),
+			prior: countState(),
+		},
+		"elos records count SESSION owner_id=2": {
+			args:  []string{"count", "SESSION", "owner_id=2"},
+			out:   []byte("1\n"),
+			prior: countState(),
+		},
+		// }}}
+
+		// elos records query {{{
+		"elos records query unknown kind": {
+			args:  []string{"query", "NOPE"},
+			code:  failure,
+			err:   []byte("[elos records] Error: unknown kind \"NOPE\", see 'elos records kinds'\n"),
+			prior: countState(),
+		},
+		"elos records query filtered": {
+			args:  []string{"query", "SESSION", "owner_id=3"},
+			out:   []byte("0 results\n"),
+			prior: countState(),
+		},
+		"elos records query table": {
+			args:  []string{"query", "USER"},
+			out:   []byte("ID  OWNER_ID  CREATED_AT\n1             \n2             \n2 results\n"),
+			prior: countState(),
+		},
+		"elos records query -format json": {
+			args:  []string{"query", "-format", "json", "USER", "id=1"},
+			out:   []byte(`{"id":"1"}` + "\n1 results\n"),
+			prior: countState(),
+		},
+		"elos records query -format xml": {
+			args:  []string{"query", "-format", "xml", "USER"},
+			code:  failure,
+			err:   []byte("[elos records] Error: unknown format \"xml\", expected json or table\n"),
+			prior: countState(),
+		},
+		// }}}
+
+		// elos records get {{{
+		"elos records get USER 1": {
+			args:  []string{"get", "USER", "1"},
+			out:   []byte("{\n    \"id\": \"1\"\n}\n"),
+			prior: countState(),
+		},
+		"elos records get USER 9": {
+			args:  []string{"get", "USER", "9"},
+			code:  failure,
+			err:   []byte("[elos records] Error: no USER with id \"9\"\n"),
+			prior: countState(),
+		},
+		// }}}
+
+		// elos records delete {{{
+		"elos records delete SESSION 5": {
+			args:  []string{"delete", "SESSION", "5"},
+			in:    []byte("y\n"),
+			out:   []byte("Delete SESSION 5? [y to confirm]Deleted SESSION 5\n"),
+			prior: countState(),
+			posterior: func() data.State {
+				s := countState()
+				s[models.Kind_SESSION] = s[models.Kind_SESSION][1:]
+				return s
+			}(),
+		},
+		"elos records delete SESSION 5 cancelled": {
+			args:  []string{"delete", "SESSION", "5"},
+			in:    []byte("n\n"),
+			out:   []byte("Delete SESSION 5? [y to confirm]Cancelled\n"),
+			prior: countState(),
+		},
+		"elos records delete SESSION 4 of another user": {
+			args:  []string{"delete", "SESSION", "4"},
+			code:  failure,
+			err:   []byte("[elos records] Error: SESSION \"4\" is not owned by you\n"),
+			prior: countState(),
 		},
 		// }}}
 
-		// TODO(nclandolfi) test query and changes
+		// TODO(nclandolfi) test changes
 
 	}
 
@@ -0,0 +1,121 @@
+package command
+
+import (
+	"net"
+	"time"
+
+	"github.com/elos/data"
+)
+
+// retryBackoff is how long a RetryDB waits before its first retry,
+// it waits twice as long before each further retry
+var retryBackoff = 100 * time.Millisecond
+
+// RetryDB is a data.DB which retries the operations of the DB it
+// decorates, so that a transient failure, i.e., a network blip talking
+// to an HTTP DB, doesn't fail the command outright.
+//
+// Only transient failures are retried, see transient. Any other
+// failure, i.e., the record wasn't found or the credentials were
+// refused, would only fail again.
+type RetryDB struct {
+	data.DB
+
+	// Retries is the number of times a failed operation is retried
+	Retries int
+}
+
+// NewRetryDB constructs a RetryDB, which retries the operations
+// of the db the given number of times.
+func NewRetryDB(db data.DB, retries int) *RetryDB {
+	return &RetryDB{
+		DB:      db,
+		Retries: retries,
+	}
+}
+
+// statusCoder is implemented by the errors of failed
+// requests which carry the HTTP status code of the response
+type statusCoder interface {
+	StatusCode() int
+}
+
+// transient indicates whether the error is worth retrying, which
+// network errors, and the 5xx responses of a server, are
+func transient(err error) bool {
+	switch e := err.(type) {
+	case net.Error:
+		return true
+	case statusCoder:
+		return e.StatusCode() >= 500
+	default:
+		return false
+	}
+}
+
+// retry calls op until it succeeds, fails with an error which isn't
+// transient, or has been retried db.Retries times, backing off
+// exponentially.
+func (db *RetryDB) retry(op func() error) error {
+	delay := retryBackoff
+
+	err := op()
+	for i := 0; i < db.Retries && err != nil && transient(err); i++ {
+		time.Sleep(delay)
+		delay *= 2
+		err = op()
+	}
+
+	return err
+}
+
+func (db *RetryDB) Save(r data.Record) error {
+	return db.retry(func() error { return db.DB.Save(r) })
+}
+
+func (db *RetryDB) Delete(r data.Record) error {
+	return db.retry(func() error { return db.DB.Delete(r) })
+}
+
+func (db *RetryDB) PopulateByID(r data.Record) error {
+	return db.retry(func() error { return db.DB.PopulateByID(r) })
+}
+
+func (db *RetryDB) PopulateByField(field string, value interface{}, r data.Record) error {
+	return db.retry(func() error { return db.DB.PopulateByField(field, value, r) })
+}
+
+func (db *RetryDB) Query(k data.Kind) data.Query {
+	return retryQuery{Query: db.DB.Query(k), db: db}
+}
+
+// retryQuery is a data.Query which retries its execution. Each
+// method which refines the query returns a retryQuery in turn.
+type retryQuery struct {
+	data.Query
+	db *RetryDB
+}
+
+func (q retryQuery) Select(a data.AttrMap) data.Query {
+	return retryQuery{Query: q.Query.Select(a), db: q.db}
+}
+
+func (q retryQuery) Limit(n int) data.Query {
+	return retryQuery{Query: q.Query.Limit(n), db: q.db}
+}
+
+func (q retryQuery) Skip(n int) data.Query {
+	return retryQuery{Query: q.Query.Skip(n), db: q.db}
+}
+
+func (q retryQuery) Order(fields ...string) data.Query {
+	return retryQuery{Query: q.Query.Order(fields...), db: q.db}
+}
+
+func (q retryQuery) Execute() (iter data.Iterator, err error) {
+	err = q.db.retry(func() error {
+		iter, err = q.Query.Execute()
+		return err
+	})
+	return
+}
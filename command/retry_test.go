@@ -0,0 +1,140 @@
+package command
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/elos/data"
+	"github.com/elos/data/builtin/mem"
+)
+
+// httpDB is a data.DB which makes a request to a server before
+// each save, failing if the server doesn't respond OK, as an
+// HTTP DB would
+type httpDB struct {
+	data.DB
+	url string
+}
+
+// statusError is the error of a request which got a bad status code
+type statusError int
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("bad status code: %d", int(e))
+}
+
+func (e statusError) StatusCode() int {
+	return int(e)
+}
+
+func (db httpDB) Save(r data.Record) error {
+	resp, err := http.Get(db.url)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp.StatusCode)
+	}
+
+	return db.DB.Save(r)
+}
+
+// flakyServer responds with the status to the first
+// failures requests, and OK to every one after
+func flakyServer(failures, status int) (*httptest.Server, *int) {
+	requests := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= failures {
+			w.WriteHeader(status)
+		}
+	})), &requests
+}
+
+func TestRetryDB(t *testing.T) {
+	defer func(d time.Duration) { retryBackoff = d }(retryBackoff)
+	retryBackoff = time.Millisecond
+
+	cases := map[string]struct {
+		failures, retries int
+		status            int
+		requests          int
+		fails             bool
+	}{
+		"no failures":  {failures: 0, retries: 2, status: http.StatusServiceUnavailable, requests: 1},
+		"recovers":     {failures: 2, retries: 2, status: http.StatusServiceUnavailable, requests: 3},
+		"gives up":     {failures: 2, retries: 1, status: http.StatusServiceUnavailable, requests: 2, fails: true},
+		"no retries":   {failures: 1, retries: 0, status: http.StatusServiceUnavailable, requests: 1, fails: true},
+		"unauthorized": {failures: 1, retries: 2, status: http.StatusUnauthorized, requests: 1, fails: true},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			s, requests := flakyServer(c.failures, c.status)
+			defer s.Close()
+
+			mdb := mem.NewDB()
+			db := NewRetryDB(httpDB{DB: mdb, url: s.URL}, c.retries)
+
+			u := newTestUser(t, mdb)
+			err := db.Save(u)
+			if c.fails && err == nil {
+				t.Fatal("Expected the save to fail")
+			}
+			if !c.fails && err != nil {
+				t.Fatalf("Expected the save to succeed, got: %s", err)
+			}
+
+			if *requests != c.requests {
+				t.Fatalf("Expected %d requests, got %d", c.requests, *requests)
+			}
+		})
+	}
+}
+
+// notFoundDB is a data.DB which never finds a record
+type notFoundDB struct {
+	data.DB
+	calls int
+}
+
+func (db *notFoundDB) PopulateByID(r data.Record) error {
+	db.calls++
+	return data.ErrNotFound
+}
+
+func TestRetryDBNotFound(t *testing.T) {
+	mdb := mem.NewDB()
+	nf := &notFoundDB{DB: mdb}
+	db := NewRetryDB(nf, 3)
+
+	if err := db.PopulateByID(newTestUser(t, mdb)); err != data.ErrNotFound {
+		t.Fatalf("Expected data.ErrNotFound, got: %v", err)
+	}
+
+	if nf.calls != 1 {
+		t.Fatalf("Expected a record which isn't found not to be retried, got %d calls", nf.calls)
+	}
+}
+
+// TestRetryQuery tests that refining a query keeps its retries
+func TestRetryQuery(t *testing.T) {
+	db := NewRetryDB(mem.NewDB(), 1)
+	q := db.Query(data.Kind("user"))
+
+	for n, refined := range map[string]data.Query{
+		"Select": q.Select(data.AttrMap{"id": "1"}),
+		"Limit":  q.Limit(1),
+		"Skip":   q.Skip(1),
+		"Order":  q.Order("id"),
+	} {
+		if _, ok := refined.(retryQuery); !ok {
+			t.Fatalf("Expected %s to return a retryQuery, got %T", n, refined)
+		}
+	}
+}
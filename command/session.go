@@ -3,6 +3,7 @@ package command
 import (
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"time"
 
@@ -47,16 +48,87 @@ func (s *Session) Start() {
 
 	for i := range s.input {
 		// we block so that the text ui can read in our absence
-		s.run(strings.Split(i, " "))
+		s.run(i)
 	}
 }
 
-func (s *Session) run(args []string) {
+// defaultCommand is the command a message is for, when
+// it doesn't start with the name of a command
+const defaultCommand = "todo"
+
+// run runs the command in the message. The first two words are the
+// command and subcommand, and any flags following them, i.e., the -r
+// of "todo tag -r", are passed to the command too. Any further words
+// are kept by the TextUI and each used to answer one of the command's
+// prompts, so that a command like "people new Nick Landolfi n" can be
+// sent as a single message.
+//
+// A message which doesn't name a command is for the default command,
+// so "new" is equivalent to "todo new". An empty message, "help" or
+// "menu" gets the list of available commands.
+func (s *Session) run(msg string) {
+	ui := NewTextUI(s.input, s.Output)
+	commands := s.commands(ui)
+
+	words := strings.Fields(msg)
+	if len(words) == 0 || words[0] == "help" || words[0] == "menu" {
+		s.Output <- menu(commands)
+		return
+	}
+
+	if _, ok := commands[words[0]]; !ok {
+		words = append([]string{defaultCommand}, words...)
+	}
+
+	n := len(words)
+	if n > 2 {
+		n = 2
+		for n < len(words) && strings.HasPrefix(words[n], "-") {
+			n++
+		}
+	}
+
+	args := words[:n]
+	ui.pending = words[n:]
+
 	// construct a new CLI with name and version
-	c := cli.NewCLI("elos", "0.1")
+	c := cli.NewCLI("elos", Version)
 	c.Args = args
-	ui := NewTextUI(s.input, s.Output)
-	c.Commands = map[string]cli.CommandFactory{
+	c.Commands = commands
+
+	_, err := c.Run()
+	if err != nil {
+		log.Printf("command session error: %s", err)
+	}
+}
+
+// menu constructs the list of available commands, from the
+// synopses of the given commands.
+func menu(commands map[string]cli.CommandFactory) string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := []string{"Available commands:"}
+	for _, name := range names {
+		c, err := commands[name]()
+		if err != nil {
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("%s - %s", name, c.Synopsis()))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// commands constructs the commands available over the session,
+// all of which act on behalf of the session's user and use the
+// session's db.
+func (s *Session) commands(ui cli.Ui) map[string]cli.CommandFactory {
+	return map[string]cli.CommandFactory{
 		"habit": func() (cli.Command, error) {
 			return &HabitCommand{
 				UI:     ui,
@@ -93,11 +165,6 @@ func (s *Session) run(args []string) {
 			}, nil
 		},
 	}
-
-	_, err := c.Run()
-	if err != nil {
-		log.Printf("command session error: %s", err)
-	}
 }
 
 // A TextUI is used for making command line interfaces
@@ -106,6 +173,10 @@ func (s *Session) run(args []string) {
 type TextUI struct {
 	in  <-chan string
 	out chan<- string
+
+	// pending are the words which have been received,
+	// but not yet used to answer a prompt
+	pending []string
 }
 
 // Constructs a new text ui
@@ -123,11 +194,21 @@ func (u *TextUI) send(txt string) {
 
 // Ask asks the user for input using the given query. The response is
 // returned as the given string, or an error.
+//
+// The words left over from the message which ran the command each
+// answer one prompt. After those, each message is a whole answer,
+// so an answer may contain spaces, i.e., a task named "call mom".
 func (u *TextUI) Ask(s string) (string, error) {
 	u.send(s)
+	if len(u.pending) > 0 {
+		answer := u.pending[0]
+		u.pending = u.pending[1:]
+		return answer, nil
+	}
+
 	select {
 	case msg := <-u.in:
-		return msg, nil
+		return strings.TrimSpace(msg), nil
 	case <-time.After(5 * time.Minute):
 		u.out <- "timeout"
 		return "", fmt.Errorf("TextUI Ask, timeout")
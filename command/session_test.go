@@ -0,0 +1,215 @@
+package command
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elos/data"
+	"github.com/elos/data/builtin/mem"
+	"github.com/elos/models"
+	xmodels "github.com/elos/x/models"
+)
+
+func newTestSession(t *testing.T) (data.DB, *models.User, chan string, chan string, *Session) {
+	db := mem.NewDB()
+	user := newTestUser(t, db)
+	input := make(chan string, 10)   // buffered, so answers can be sent before they're asked for
+	output := make(chan string, 100) // buffered, so the commands never block
+
+	return db, user, input, output, NewSession(user, db, input, output, func() {})
+}
+
+// --- session: a single message drives a whole command {{{
+func TestSessionSingleMessage(t *testing.T) {
+	db, user, _, output, s := newTestSession(t)
+
+	// command, subcommand, then first name, last name and no contact details
+	s.run("people new Nick Landolfi n")
+	close(output)
+
+	for msg := range output {
+		t.Logf("Output: %s", msg)
+	}
+
+	iter, err := db.Query(models.PersonKind).Select(data.AttrMap{
+		"owner_id": user.ID().String(),
+	}).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := models.NewPerson()
+	if !iter.Next(p) {
+		t.Fatal("Expected the person to have been saved")
+	}
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.FirstName != "Nick" || p.LastName != "Landolfi" {
+		t.Fatalf("Expected Nick Landolfi, got %s %s", p.FirstName, p.LastName)
+	}
+}
+
+// --- }}}
+
+// --- session: `todo new` {{{
+func TestSessionTodoNew(t *testing.T) {
+	db, _, _, output, s := newTestSession(t)
+
+	// name, then no deadline, no estimate and no prerequisites
+	s.run("new groceries n n n")
+	close(output)
+
+	for msg := range output {
+		t.Logf("Output: %s", msg)
+	}
+
+	tsk := new(xmodels.Task)
+	if err := db.PopulateByField("name", "groceries", tsk); err != nil {
+		t.Fatalf("Expected the task to have been saved: %s", err)
+	}
+}
+
+// TestSessionMultiWordAnswer tests that an answer sent
+// as its own message is used whole, spaces and all
+func TestSessionMultiWordAnswer(t *testing.T) {
+	db, _, input, output, s := newTestSession(t)
+
+	for _, answer := range []string{"call mom", "n", "n", "n"} {
+		input <- answer
+	}
+
+	s.run("todo new")
+	close(output)
+
+	for msg := range output {
+		t.Logf("Output: %s", msg)
+	}
+
+	tsk := new(xmodels.Task)
+	if err := db.PopulateByField("name", "call mom", tsk); err != nil {
+		t.Fatalf("Expected the task 'call mom' to have been saved: %s", err)
+	}
+
+	if !tsk.DeadlineAt.IsZero() {
+		t.Fatal("Expected 'call mom' to have no deadline")
+	}
+}
+
+// --- }}}
+
+// --- session: flags {{{
+
+// TestSessionFlags tests that the flags following the subcommand,
+// like the -r of `todo tag -r`, are passed to the command, rather
+// than used as answers
+func TestSessionFlags(t *testing.T) {
+	db, user, _, output, s := newTestSession(t)
+
+	tsk := new(xmodels.Task)
+	tsk.SetID(db.NewID())
+	tsk.OwnerId = user.Id
+	tsk.Name = "report"
+	tsk.Tags = []string{"work"}
+	if err := db.Save(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	// remove tag 0 of task 0
+	s.run("todo tag -r 0 0")
+	close(output)
+
+	for msg := range output {
+		t.Logf("Output: %s", msg)
+	}
+
+	if err := db.PopulateByID(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tsk.Tags) != 0 {
+		t.Fatalf("Expected the tag to have been removed, got %v", tsk.Tags)
+	}
+}
+
+// --- }}}
+
+// --- session: `habit list` over the channels {{{
+func TestSessionHabitList(t *testing.T) {
+	db, user, input, output, s := newTestSession(t)
+	newTestHabit(t, db, user, "meditate")
+
+	go s.Start()
+	defer close(input)
+
+	input <- "habit list"
+
+	for {
+		select {
+		case msg := <-output:
+			t.Logf("Output: %s", msg)
+			if strings.Contains(msg, "meditate") {
+				return
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatal("timeout waiting for the habit list")
+		}
+	}
+}
+
+// --- }}}
+
+// --- session: menu {{{
+func TestSessionMenu(t *testing.T) {
+	_, _, _, output, s := newTestSession(t)
+
+	for _, msg := range []string{"", "help"} {
+		s.run(msg)
+
+		select {
+		case m := <-output:
+			t.Logf("Output: %s", m)
+			for _, name := range []string{"habit", "people", "tag", "todo"} {
+				if !strings.Contains(m, name+" - ") {
+					t.Fatalf("Expected the menu to list %s", name)
+				}
+			}
+
+			if !strings.Contains(m, (&HabitCommand{}).Synopsis()) {
+				t.Fatalf("Expected the menu to contain the synopses")
+			}
+		default:
+			t.Fatalf("Expected a menu to be sent for %q", msg)
+		}
+	}
+}
+
+// --- }}}
+
+// --- TextUI {{{
+func TestTextUIAsk(t *testing.T) {
+	in := make(chan string, 2)
+	out := make(chan string, 10)
+	ui := NewTextUI(in, out)
+
+	// the words left over from the command's message,
+	// then two messages, each a whole answer
+	ui.pending = []string{"first", "second"}
+	in <- " call mom "
+	in <- ""
+
+	for _, want := range []string{"first", "second", "call mom", ""} {
+		got, err := ui.Ask("?")
+		if err != nil {
+			t.Fatalf("ui.Ask error: %s", err)
+		}
+
+		if got != want {
+			t.Fatalf("ui.Ask: got %q, want %q", got, want)
+		}
+	}
+}
+
+// --- }}}
@@ -90,13 +90,13 @@ func (c *SetupCommand) setConfig(username, password, id string) int {
 }
 
 func (c *SetupCommand) promptNewHost() int {
-	host, err := stringInput(c.UI, "What host would you like to connect to?")
+	host, err := promptHost(c.UI, c.Config.Hosts, "What host would you like to connect to?")
 	if err != nil {
 		c.errorf("input: %s", err)
 		return failure
 	}
 
-	c.Config.Host = host
+	c.Config.UseHost(host)
 	if err := WriteConfigFile(c.Config); err != nil {
 		c.errorf("failed to persist configuration change: %s", err)
 		return failure
@@ -122,6 +122,12 @@ func (c *SetupCommand) setupCurrentUser() int {
 		return failure
 	}
 
+	// only persist credentials which the host accepts
+	if err := verifyCredentials(c.Config.Host, username, password, id); err != nil {
+		c.errorf("verifying credentials with %s: %s", c.Config.Host, err)
+		return failure
+	}
+
 	if i := c.setConfig(username, password, id); i != success {
 		return i
 	}
@@ -154,19 +160,40 @@ func (c *SetupCommand) promptNewUser() (*models.User, string, string, int) {
 		return nil, "", "", failure
 	}
 
-	params := url.Values{}
-	params.Set("username", username)
-	params.Set("password", password)
-	url := c.Config.Host + "/register/?" + params.Encode()
-	resp, err := http.Post(url, "", nil)
+	var resp *http.Response
+	for {
+		params := url.Values{}
+		params.Set("username", username)
+		params.Set("password", password)
+		url := c.Config.Host + "/register/?" + params.Encode()
 
-	if err != nil || resp.StatusCode != http.StatusCreated {
-		if err != nil {
+		var err error
+		if resp, err = http.Post(url, "", nil); err != nil {
 			c.errorf("error on POST to /register/: %s", err)
-		} else {
+			return nil, "", "", failure
+		}
+
+		if resp.StatusCode == http.StatusCreated {
+			break
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusConflict {
 			c.errorf("bade status code on POST to /register/: %d", resp.StatusCode)
+			return nil, "", "", failure
+		}
+
+		// the username is taken, so ask for another and keep the password
+		c.UI.Warn(fmt.Sprintf("The username '%s' is taken", username))
+		if username, inputError = stringInput(c.UI, "What username would you like to use?"); inputError != nil {
+			c.errorf("input error: %s", inputError)
+			return nil, "", "", failure
+		}
+
+		if username == "" {
+			c.errorf("username can't be empty")
+			return nil, "", "", failure
 		}
-		return nil, "", "", failure
 	}
 
 	u := new(models.User)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -40,9 +41,19 @@ func TestSetupCurrentUser(t *testing.T) {
 	}
 	defer f.Close()
 
+	// the host only needs to verify the credentials
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != "public" || p != "private" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}))
+	defer s.Close()
+
 	ui, conf, c := newMockSetupCommand(t)
 	conf.Path = f.Name()
-	conf.Host = "fake" // not needed here because doesn't hit api
+	conf.Host = s.URL
+	conf.Credential.Public = "grpc-public"
 
 	mem.WithData(map[data.Kind][]data.Record{
 		data.Kind(models.Kind_USER.String()): []data.Record{
@@ -103,6 +114,60 @@ func TestSetupCurrentUser(t *testing.T) {
 	if conf.PrivateCredential != "private" {
 		t.Fatalf("private credential should be: private")
 	}
+
+	// the grpc credential is set separately
+	if conf.Credential.Public != "grpc-public" {
+		t.Fatalf("grpc credential should be unchanged, got: %s", conf.Credential.Public)
+	}
+}
+
+// --- }}}
+
+// --- 'elos setup'  (context: already have an account, but bad credentials) {{{
+func TestSetupCurrentUserRejected(t *testing.T) {
+	f, err := ioutil.TempFile("", "conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// the host rejects all credentials
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer s.Close()
+
+	ui, conf, c := newMockSetupCommand(t)
+	conf.Path = f.Name()
+	conf.Host = s.URL
+
+	// yes already account, then public key and private key and user id
+	ui.InputReader = bytes.NewBufferString("y\npublic\nwrong\n1\n")
+
+	t.Log("running: `elos setup`")
+	code := c.Run([]string{})
+	t.Log("command `setup` terminated")
+
+	t.Log("Reading outputs")
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	// verify failure
+	if code == 0 {
+		t.Fatalf("Expected failure exit code")
+	}
+
+	// verify the error
+	if !strings.Contains(errput, "credentials rejected") {
+		t.Fatalf("Error output should have said the credentials were rejected")
+	}
+
+	// verify conf was not changed
+	if conf.UserID != "" || conf.PublicCredential != "" || conf.PrivateCredential != "" {
+		t.Fatalf("Configuration should not have been changed, got: %+v", conf)
+	}
 }
 
 // --- }}}
@@ -223,3 +288,73 @@ func TestSetupNewUser(t *testing.T) {
 }
 
 // --- }}}
+
+// --- 'elos setup'  (context: need a new account, username taken) {{{
+func TestSetupNewUserTaken(t *testing.T) {
+	f, err := ioutil.TempFile("", "conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// the first registration conflicts, the second succeeds
+	var usernames []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		usernames = append(usernames, r.FormValue("username"))
+		if len(usernames) == 1 {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id":"1"}`)
+	}))
+	defer s.Close()
+
+	ui, conf, c := newMockSetupCommand(t)
+	conf.Path = f.Name()
+	conf.Host = s.URL
+
+	// no already account, then username input, password input and another username
+	ui.InputReader = bytes.NewBufferString("n\ntaken\nprivate\nfree\n")
+
+	t.Log("running: `elos setup`")
+	code := c.Run([]string{})
+	t.Log("command `setup` terminated")
+
+	t.Log("Reading outputs")
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	// verify success
+	if code != 0 {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	// verify the conflict was reported
+	if !strings.Contains(errput, "taken") {
+		t.Fatalf("Error output should have said the username was taken")
+	}
+
+	// verify both usernames were tried
+	if len(usernames) != 2 || usernames[0] != "taken" || usernames[1] != "free" {
+		t.Fatalf("Expected registrations for [taken free], got %v", usernames)
+	}
+
+	// verify the second username and the original password were kept
+	if conf.PublicCredential != "free" {
+		t.Fatalf("Expected public credential to be 'free', got '%s'", conf.PublicCredential)
+	}
+
+	if conf.PrivateCredential != "private" {
+		t.Fatalf("Expected private credential to be 'private', got '%s'", conf.PrivateCredential)
+	}
+
+	if conf.UserID != "1" {
+		t.Fatalf("Expected user id to be '1', got '%s'", conf.UserID)
+	}
+}
+
+// --- }}}
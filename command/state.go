@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
+	"time"
 )
 
 const ConfigFileName = "elosconfig.json"
@@ -11,17 +12,79 @@ const ConfigFileName = "elosconfig.json"
 // The struct representing the state needed by the cli
 type Config struct {
 	// the file path of this config
-	Path                                string `json:"-"`
-	Host                                string
-	DirectDB                            bool
-	DB                                  string
+	Path string `json:"-"`
+
+	// the elos host, used for the http api
+	Host string
+
+	// the hosts which have been used, most recently used last,
+	// so that they can be switched between quickly
+	Hosts []string
+
+	// whether to dial the mongo database at DB directly,
+	// rather than going through the Host
+	DirectDB bool
+	DB       string
+
+	// credentials for the http api, and the id of their owner
 	PublicCredential, PrivateCredential string
 	UserID                              string
-	Credential                          struct {
+
+	// credentials for the grpc api
+	Credential struct {
 		Public  string
 		Private string
 		OwnerID string
 	}
+
+	// the IANA name of the time zone dates are entered in,
+	// i.e., America/Los_Angeles, if empty the local time zone
+	Timezone string
+
+	// the timeout of requests to the host, i.e., "10s",
+	// if empty requests never time out
+	Timeout string
+
+	// the number of times a failed request to the host is retried
+	Retries int
+
+	// the weights of tags, by name, which bias 'elos todo suggest'
+	// toward tasks carrying them, i.e., {"URGENT": 2}
+	TagWeights map[string]float64
+}
+
+// Location loads the location of the configured time zone,
+// which is time.Local if no time zone is configured.
+func (c *Config) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+
+	return time.LoadLocation(c.Timezone)
+}
+
+// HTTPTimeout parses the timeout of requests to the host,
+// which is 0, no timeout, if no timeout is configured.
+func (c *Config) HTTPTimeout() (time.Duration, error) {
+	if c.Timeout == "" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(c.Timeout)
+}
+
+// UseHost sets the host, and records it in the history of hosts.
+// Each host appears in the history once.
+func (c *Config) UseHost(host string) {
+	c.Host = host
+
+	hosts := make([]string, 0, len(c.Hosts)+1)
+	for _, h := range c.Hosts {
+		if h != host {
+			hosts = append(hosts, h)
+		}
+	}
+	c.Hosts = append(hosts, host)
 }
 
 // Read in the current configuration
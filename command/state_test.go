@@ -1 +1,96 @@
 package command_test
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/elos/elos/command"
+)
+
+func TestConfigRoundTrip(t *testing.T) {
+	f, err := ioutil.TempFile("", "configtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	conf := &command.Config{
+		Path:              f.Name(),
+		Host:              "http://elos.pw",
+		DirectDB:          true,
+		DB:                "localhost",
+		PublicCredential:  "public",
+		PrivateCredential: "private",
+		UserID:            "1",
+		Timezone:          "America/New_York",
+	}
+	conf.Credential.Public = "grpc-public"
+	conf.Credential.Private = "grpc-private"
+	conf.Credential.OwnerID = "2"
+
+	if err := command.WriteConfigFile(conf); err != nil {
+		t.Fatalf("WriteConfigFile error: %s", err)
+	}
+
+	read, err := command.ParseConfigFile(f.Name())
+	if err != nil {
+		t.Fatalf("ParseConfigFile error: %s", err)
+	}
+
+	if !reflect.DeepEqual(read, conf) {
+		t.Fatalf("ParseConfigFile: got %+v, want %+v", read, conf)
+	}
+}
+
+func TestParseConfigFileMissing(t *testing.T) {
+	f, err := ioutil.TempFile("", "configtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+
+	conf, err := command.ParseConfigFile(f.Name())
+	if err != nil {
+		t.Fatalf("ParseConfigFile error: %s", err)
+	}
+
+	if got, want := conf.Path, f.Name(); got != want {
+		t.Fatalf("conf.Path: got %q, want %q", got, want)
+	}
+
+	if conf.Host != "" || conf.UserID != "" {
+		t.Fatalf("Expected an empty configuration, got %+v", conf)
+	}
+}
+
+func TestConfigLocation(t *testing.T) {
+	conf := &command.Config{}
+
+	loc, err := conf.Location()
+	if err != nil {
+		t.Fatalf("conf.Location error: %s", err)
+	}
+
+	if loc != time.Local {
+		t.Fatalf("conf.Location: got %v, want time.Local", loc)
+	}
+
+	conf.Timezone = "UTC"
+	if loc, err = conf.Location(); err != nil {
+		t.Fatalf("conf.Location error: %s", err)
+	}
+
+	if loc.String() != "UTC" {
+		t.Fatalf("conf.Location: got %v, want UTC", loc)
+	}
+
+	conf.Timezone = "Nowhere/Special"
+	if _, err := conf.Location(); err == nil {
+		t.Fatal("conf.Location: expected an error for an unknown time zone")
+	}
+}
@@ -39,16 +39,132 @@ func (c *StreamCommand) Synopsis() string {
 func (c *StreamCommand) Help() string {
 	helpText := `
 Usage:
-	elos stream		start streaming the events
+	elos stream [options]		start streaming the events
+
+Options:
+	-kind <kind>	stream records of the kind, rather than events
+	-tag <name>	only stream records carrying the tag
+	-heartbeat <d>	the interval between heartbeats, i.e., 30s (default 5s)
+	-quiet		don't print heartbeats
+	-reconnect	reconnect, with backoff, when the server closes the stream
 	`
 	return strings.TrimSpace(helpText)
 }
 
+// streamOptions are the options accepted by the 'stream' command
+type streamOptions struct {
+	// kind is the kind of record to stream
+	kind data.Kind
+
+	// tag, if not empty, is the name of the tag records must carry
+	tag string
+
+	// heartbeat is the interval at which to print a heartbeat
+	// when no changes have arrived
+	heartbeat time.Duration
+
+	// quiet indicates to not print the heartbeats
+	quiet bool
+
+	// reconnect indicates to reconnect when the server closes
+	// the stream, rather than exiting
+	reconnect bool
+}
+
+// reconnection parameters of 'stream -reconnect', the delay before
+// reconnecting doubles with each consecutive attempt, up to the max.
+// A change arriving resets the attempts.
+var (
+	reconnectDelay    = 1 * time.Second
+	maxReconnectDelay = 30 * time.Second
+	maxReconnects     = 5
+)
+
+// parseStreamOptions parses the arguments to the 'stream' command,
+// streaming events by default.
+func parseStreamOptions(args []string) (*streamOptions, error) {
+	opts := &streamOptions{
+		kind:      models.EventKind,
+		heartbeat: 5 * time.Second,
+	}
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-kind":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("-kind requires a kind")
+			}
+			i++
+			opts.kind = data.Kind(strings.ToLower(args[i]))
+		case "-tag":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("-tag requires a tag name")
+			}
+			i++
+			opts.tag = args[i]
+		case "-heartbeat":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("-heartbeat requires a duration")
+			}
+			i++
+			d, err := time.ParseDuration(args[i])
+			if err != nil {
+				return nil, err
+			}
+			if d <= 0 {
+				return nil, fmt.Errorf("-heartbeat must be positive")
+			}
+			opts.heartbeat = d
+		case "-quiet":
+			opts.quiet = true
+		case "-reconnect":
+			opts.reconnect = true
+		default:
+			return nil, fmt.Errorf("unrecognized option %q", args[i])
+		}
+	}
+
+	return opts, nil
+}
+
+// tagged is implemented by the records which can carry tags
+type tagged interface {
+	Tags(data.DB) ([]*models.Tag, error)
+}
+
+// hasTag checks whether the record carries a tag with the given
+// name. Records which can't carry tags never do.
+func (c *StreamCommand) hasTag(r data.Record, name string) (bool, error) {
+	t, ok := r.(tagged)
+	if !ok {
+		return false, nil
+	}
+
+	tags, err := t.Tags(c.DB)
+	if err != nil {
+		return false, err
+	}
+
+	for _, tg := range tags {
+		if tg.Name == name {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 func (c *StreamCommand) Run(args []string) int {
 	if c.UI == nil {
 		return failure
 	}
 
+	opts, err := parseStreamOptions(args)
+	if err != nil {
+		c.errorf("%s", err)
+		return failure
+	}
+
 	if c.UserID == "" {
 		c.errorf("no user id")
 		return failure
@@ -61,28 +177,67 @@ func (c *StreamCommand) Run(args []string) int {
 
 	changes := *c.DB.Changes()
 
+	attempts, delay := 0, reconnectDelay
+
 	for {
 		select {
 		case change, ok := <-changes:
 			if !ok {
 				c.UI.Output("Connection closed by server")
-				return success
+				if !opts.reconnect {
+					return success
+				}
+
+				if attempts == maxReconnects {
+					c.errorf("giving up after %d attempts to reconnect", attempts)
+					return failure
+				}
+				attempts++
+
+				c.UI.Output(fmt.Sprintf("reconnecting in %s...", delay))
+				time.Sleep(delay)
+				if delay *= 2; delay > maxReconnectDelay {
+					delay = maxReconnectDelay
+				}
+
+				changes = *c.DB.Changes()
+				continue
 			}
 
+			attempts, delay = 0, reconnectDelay
+
 			if change.ChangeKind != data.Update && change.ChangeKind != data.Create {
 				continue
 			}
 
-			if change.Record.Kind() != models.EventKind {
+			if change.Record.Kind() != opts.kind {
 				continue
 			}
 
-			e := change.Record.(*models.Event)
+			if opts.tag != "" {
+				ok, err := c.hasTag(change.Record, opts.tag)
+				if err != nil {
+					c.errorf("retrieving tags of %s %s: %s", change.Record.Kind(), change.Record.ID(), err)
+					continue
+				}
+
+				if !ok {
+					continue
+				}
+			}
+
+			e, ok := change.Record.(*models.Event)
+			if !ok {
+				c.UI.Output(fmt.Sprintf("[%s] %s", change.Record.Kind(), change.Record.ID()))
+				continue
+			}
+
+			// errors with a single event are reported, but don't end the stream
 
 			tags, err := e.Tags(c.DB)
 			if err != nil {
-				// TODO errorf
-				return failure
+				c.errorf("retrieving tags of event %s: %s", e.ID(), err)
+				continue
 			}
 
 			tagString := ""
@@ -97,8 +252,8 @@ func (c *StreamCommand) Run(args []string) int {
 
 			loc, err := e.Location(c.DB)
 			if err != nil && err != models.ErrEmptyLink {
-				// TODO errorf
-				return failure
+				c.errorf("retrieving location of event %s: %s", e.ID(), err)
+				continue
 			}
 
 			locString := ""
@@ -109,14 +264,16 @@ func (c *StreamCommand) Run(args []string) int {
 
 			n, err := e.Note(c.DB)
 			if err != nil && err != models.ErrEmptyLink {
-				// TODO errorf
-				return failure
+				c.errorf("retrieving note of event %s: %s", e.ID(), err)
+				continue
 			}
 			if n != nil {
 				c.UI.Output(fmt.Sprintf("\tNote: %s", n.Text))
 			}
-		case <-time.After(5 * time.Second):
-			c.UI.Output("5 second heartbeat")
+		case <-time.After(opts.heartbeat):
+			if !opts.quiet {
+				c.UI.Output(fmt.Sprintf("%s heartbeat", opts.heartbeat))
+			}
 		}
 	}
 
@@ -80,3 +80,149 @@ func TestStream(t *testing.T) {
 }
 
 // --- }}}
+
+// --- `elos stream` with an event's location {{{
+
+// TestStreamEventLocation tests that the `stream` command prints
+// the coordinates of an event created with `elos event new`
+func TestStreamEventLocation(t *testing.T) {
+	ui, db, user, c := newMockStreamCommand(t)
+
+	// in another go routine start streaming
+	go c.Run([]string{"-quiet"})
+	time.Sleep(10 * time.Millisecond) // give the go routine time to start listening
+
+	event := &EventCommand{
+		UI:     new(cli.MockUi),
+		UserID: user.ID().String(),
+		DB:     db,
+	}
+
+	t.Log("running: `elos event new -name lunch -lat 1.5 -lon 2.5`")
+	if code := event.Run([]string{"new", "-name", "lunch", "-lat", "1.5", "-lon", "2.5"}); code != success {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	time.Sleep(50 * time.Millisecond) // give the go routine running command time to process
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	if !strings.Contains(output, "lunch (lat: 1.500000, lon: 2.500000") {
+		t.Fatalf("Output should have the event's coordinates")
+	}
+}
+
+// --- }}}
+
+// --- `elos stream` with a broken event {{{
+
+// TestStreamSurvivesEventErrors tests that the `stream` command reports
+// an error with a single event, and goes on to stream the next one
+func TestStreamSurvivesEventErrors(t *testing.T) {
+	ui, db, user, c := newMockStreamCommand(t)
+
+	// in another go routine start streaming
+	go c.Run([]string{"-quiet"})
+	time.Sleep(10 * time.Millisecond) // give the go routine time to start listening
+
+	// a tag which is never saved, so the event's tag lookup fails
+	missing := models.NewTag()
+	missing.SetID(db.NewID())
+
+	broken := models.NewEvent()
+	broken.SetID(db.NewID())
+	broken.SetOwner(user)
+	broken.Name = "broken event"
+	broken.IncludeTag(missing)
+	if err := db.Save(broken); err != nil {
+		t.Fatal(err)
+	}
+
+	e := models.NewEvent()
+	e.SetID(db.NewID())
+	e.SetOwner(user)
+	eventName := "event name"
+	e.Name = eventName
+	if err := db.Save(e); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(50 * time.Millisecond) // give the go routine running command time to process
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify the broken event was reported
+	if !strings.Contains(errput, "retrieving tags of event") {
+		t.Fatalf("Expected an error retrieving the broken event's tags, got: %s", errput)
+	}
+
+	// verify the stream went on to the next event
+	if !strings.Contains(output, eventName) {
+		t.Fatalf("Output should have the second event's name: '%s'", eventName)
+	}
+}
+
+// --- }}}
+
+// --- `elos stream -reconnect` {{{
+
+// closedDB is a data.DB whose changes channel is always closed,
+// as if the server immediately closes every connection
+type closedDB struct {
+	data.DB
+
+	// connections counts the calls to Changes
+	connections int
+}
+
+func (db *closedDB) Changes() *chan *data.Change {
+	db.connections++
+	ch := make(chan *data.Change)
+	close(ch)
+	return &ch
+}
+
+// TestStreamReconnect tests that the `stream` command, with -reconnect,
+// reconnects when the server closes the stream, and eventually gives up
+func TestStreamReconnect(t *testing.T) {
+	ui, _, _, c := newMockStreamCommand(t)
+	db := &closedDB{DB: c.DB}
+	c.DB = db
+
+	defer func(d time.Duration) { reconnectDelay = d }(reconnectDelay)
+	reconnectDelay = time.Millisecond
+
+	if code := c.Run([]string{"-quiet", "-reconnect"}); code != failure {
+		t.Fatalf("Expected failure exit code, got %d", code)
+	}
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	if got, want := db.connections, maxReconnects+1; got != want {
+		t.Fatalf("Expected %d connections, got %d", want, got)
+	}
+
+	if !strings.Contains(output, "reconnecting") {
+		t.Fatalf("Output should have said it was reconnecting")
+	}
+
+	if !strings.Contains(errput, "giving up") {
+		t.Fatalf("Expected an error giving up, got: %s", errput)
+	}
+}
+
+// --- }}}
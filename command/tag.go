@@ -9,6 +9,8 @@ import (
 	"github.com/elos/data"
 	"github.com/elos/models"
 	"github.com/elos/models/tag"
+	xmodels "github.com/elos/x/models/proto"
+	xtag "github.com/elos/x/models/tag"
 	"github.com/mitchellh/cli"
 )
 
@@ -51,7 +53,8 @@ Usage:
 Subcommands:
 	delete		delete a tag
 	edit		edit a tag
-	list		list all your tags
+	list (-c|--json)	list all your tags (by usage count, as JSON)
+	merge		merge one tag into another
 	new		create a new tag
 `
 	return strings.TrimSpace(helpText)
@@ -75,24 +78,25 @@ func (c *TagCommand) Run(args []string) int {
 		return i
 	}
 
-	switch len(args) {
-	case 1:
-		switch args[0] {
-		case "e":
-		case "edit":
-			return c.runEdit(args)
-		case "d":
-		case "delete":
-			return c.runDelete(args)
-		case "l":
-		case "list":
-			return c.runList(args)
-		case "n":
-		case "new":
-			return c.runNew(args)
-		default:
-			c.UI.Output(c.Help())
+	switch args[0] {
+	case "e":
+	case "edit":
+		return c.runEdit(args)
+	case "d":
+	case "delete":
+		return c.runDelete(args)
+	case "l":
+	case "list":
+		if len(args) == 2 && args[1] == "-c" {
+			return c.runListCounts(args)
 		}
+
+		return c.runList(args)
+	case "merge":
+		return c.runMerge(args)
+	case "n":
+	case "new":
+		return c.runNew(args)
 	default:
 		c.UI.Output(c.Help())
 	}
@@ -136,7 +140,7 @@ func (c *TagCommand) init() int {
 	}).Execute()
 
 	if err != nil {
-		c.errorf("data retrieval: querying tags")
+		c.errorf("data retrieval: querying tags: %v", err)
 		return failure
 	}
 
@@ -148,7 +152,7 @@ func (c *TagCommand) init() int {
 	}
 
 	if err := iter.Close(); err != nil {
-		c.errorf("data retrieval: querying tags")
+		c.errorf("data retrieval: querying tags: %v", err)
 		return failure
 	}
 
@@ -166,6 +170,56 @@ func (c *TagCommand) errorf(s string, values ...interface{}) {
 	c.UI.Error("[elos tag] Error: " + fmt.Sprintf(s, values...))
 }
 
+// removeTag removes the tag at the given index.
+// You may use this for removing a tag from memory after
+// it has been deleted.
+func (c *TagCommand) removeTag(index int) {
+	c.tags = append(c.tags[:index], c.tags[index+1:]...)
+}
+
+// retagTasks rewrites the tags of every task carrying the tag named from,
+// so that they carry the tag named to instead. If to is empty, the tag is
+// simply removed from the tasks. A task never ends up with a tag twice.
+// The pseudo-tags elos keeps on tasks can't be retagged.
+//
+// It returns the number of tasks which were changed.
+func (c *TagCommand) retagTasks(from, to string) (int, error) {
+	for _, name := range []string{from, to} {
+		if isPseudoTag(name) {
+			return 0, fmt.Errorf("%q is reserved for elos", name)
+		}
+	}
+
+	tasks, err := xtag.TasksFor(c.DB, c.UserID, from)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, t := range tasks {
+		tags := make([]string, 0, len(t.Tags))
+		seen := make(map[string]bool)
+		for _, name := range t.Tags {
+			if name == from {
+				name = to
+			}
+
+			if name == "" || seen[name] {
+				continue
+			}
+
+			seen[name] = true
+			tags = append(tags, name)
+		}
+		t.Tags = tags
+
+		if err := c.DB.Save(t); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(tasks), nil
+}
+
 func (c *TagCommand) runEdit(args []string) int {
 	tg, index := c.promptSelectTag()
 	if index < 0 {
@@ -184,9 +238,21 @@ func (c *TagCommand) runEdit(args []string) int {
 		return failure
 	}
 
+	oldName := tg.Name
+
 	switch attributeToEdit {
 	case "name":
-		tg.Name, err = stringInput(c.UI, "Name")
+		var name string
+		if name, err = stringInput(c.UI, "Name"); err != nil {
+			break
+		}
+
+		if existing := c.existingTag(name, tg); existing != nil {
+			c.UI.Warn(fmt.Sprintf("Tag already exists: '%s', use 'elos tag merge' to combine them", existing.Name))
+			return success
+		}
+
+		tg.Name = name
 	default:
 		c.UI.Warn("That attribute is not recognized/supported")
 		return success
@@ -197,6 +263,17 @@ func (c *TagCommand) runEdit(args []string) int {
 		return failure
 	}
 
+	// tasks reference tags by name, so a rename must be carried to them
+	if tg.Name != oldName {
+		n, err := c.retagTasks(oldName, tg.Name)
+		if err != nil {
+			c.errorf("(subcommand edit) Error: %s", err)
+			return failure
+		}
+
+		c.UI.Output(fmt.Sprintf("Renamed on %d tasks", n))
+	}
+
 	if err = c.DB.Save(tg); err != nil {
 		c.errorf("(subcommand edit) Error: %s", err)
 		return failure
@@ -227,12 +304,74 @@ func (c *TagCommand) runDelete(args []string) int {
 		return success
 	}
 
+	// strip the tag from its tasks first, so none are left dangling
+	n, err := c.retagTasks(tg.Name, "")
+	if err != nil {
+		c.errorf("(subcommand delete) Error: %s", err)
+		return failure
+	}
+
 	if err := c.DB.Delete(tg); err != nil {
 		c.errorf("(subcommand delete) Error: %s", err)
 		return failure
 	}
 
+	c.removeTag(index)
+
 	c.UI.Info(fmt.Sprintf("Deleted '%s'", tg.Name))
+	c.UI.Info(fmt.Sprintf("Removed from %d tasks", n))
+	return success
+}
+
+// runMerge runs the 'merge' subcommand.
+//
+// It prompts for a source and a destination tag, moves every task
+// carrying the source tag onto the destination tag, and then deletes
+// the source tag.
+//
+// It returns an exit status:
+// 0 := success
+// 1 := failure
+func (c *TagCommand) runMerge(args []string) int {
+	c.UI.Output("Which tag to merge from?")
+	from, fromIndex := c.promptSelectTag()
+	if fromIndex < 0 {
+		return failure
+	}
+
+	c.UI.Output("Which tag to merge into?")
+	to, toIndex := c.promptSelectTag()
+	if toIndex < 0 {
+		return failure
+	}
+
+	if fromIndex == toIndex {
+		c.UI.Warn("Can't merge a tag into itself")
+		return failure
+	}
+
+	if confirm, err := yesNo(c.UI, fmt.Sprintf("Merge '%s' into '%s'?", from.Name, to.Name)); err != nil {
+		c.errorf("Input Error: %s", err)
+		return failure
+	} else if !confirm {
+		c.UI.Info("Cancelled")
+		return success
+	}
+
+	n, err := c.retagTasks(from.Name, to.Name)
+	if err != nil {
+		c.errorf("(subcommand merge) Error: %s", err)
+		return failure
+	}
+
+	if err := c.DB.Delete(from); err != nil {
+		c.errorf("(subcommand merge) Error: %s", err)
+		return failure
+	}
+
+	c.removeTag(fromIndex)
+
+	c.UI.Info(fmt.Sprintf("Merged '%s' into '%s' (%d tasks)", from.Name, to.Name, n))
 	return success
 }
 
@@ -240,6 +379,14 @@ func (c *TagCommand) runDelete(args []string) int {
 //
 // It returns an exit status, always success
 func (c *TagCommand) runList(args []string) int {
+	if hasFlag(args, "json") {
+		if err := outputJSON(c.UI, c.tags); err != nil {
+			c.errorf("encoding tags: %s", err)
+			return failure
+		}
+		return success
+	}
+
 	if len(c.tags) == 0 {
 		c.UI.Output("You don't have any tags")
 	} else {
@@ -248,6 +395,97 @@ func (c *TagCommand) runList(args []string) int {
 	return success
 }
 
+// runListCounts runs the 'list -c' subcommand. It prints the tags
+// along with the number of tasks which carry each of them, most
+// used first.
+//
+// It returns an exit status:
+// 0 := success
+// 1 := failure
+func (c *TagCommand) runListCounts(args []string) int {
+	if len(c.tags) == 0 {
+		c.UI.Output("You don't have any tags")
+		return success
+	}
+
+	counts, err := c.tagCounts()
+	if err != nil {
+		c.errorf("data retrieval: counting tasks: %s", err)
+		return failure
+	}
+
+	usage := make([]tagUsage, len(c.tags))
+	for i, t := range c.tags {
+		usage[i] = tagUsage{index: i, tag: t, count: counts[t.Name]}
+	}
+
+	// stable, so that ties stay in alphabetical order
+	sort.Stable(byCount(usage))
+
+	for _, u := range usage {
+		c.UI.Output(fmt.Sprintf("%d) %s [%d]", u.index, u.tag.Name, u.count))
+	}
+
+	return success
+}
+
+// tagCounts returns the number of tasks which carry each tag name,
+// not counting the pseudo-tags elos keeps on tasks.
+//
+// It queries the user's tasks once and tallies the tags in memory,
+// rather than querying the tasks of each tag individually.
+func (c *TagCommand) tagCounts() (map[string]int, error) {
+	iter, err := c.DB.Query(data.Kind(xmodels.Kind_TASK.String())).Select(data.AttrMap{
+		"owner_id": c.UserID,
+	}).Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	t := new(xmodels.Task)
+	for iter.Next(t) {
+		seen := make(map[string]bool)
+		for _, name := range userTags(t) {
+			if !seen[name] {
+				counts[name]++
+				seen[name] = true
+			}
+		}
+		t = new(xmodels.Task)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// tagUsage associates a tag, and its index in c.tags, with the
+// number of tasks which carry it.
+type tagUsage struct {
+	index int
+	tag   *models.Tag
+	count int
+}
+
+// byCount is a type which satisfies the sort.Interface
+// and sorts tag usages by their count, highest first
+type byCount []tagUsage
+
+func (b byCount) Len() int {
+	return len(b)
+}
+
+func (b byCount) Less(i, j int) bool {
+	return b[i].count > b[j].count
+}
+
+func (b byCount) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
 func (c *TagCommand) runNew(args []string) int {
 	t := models.NewTag()
 	t.SetID(c.DB.NewID())
@@ -259,14 +497,35 @@ func (c *TagCommand) runNew(args []string) int {
 		return failure
 	}
 
+	if existing := c.existingTag(t.Name, nil); existing != nil {
+		c.UI.Warn(fmt.Sprintf("Tag already exists: '%s'", existing.Name))
+		return success
+	}
+
 	if err := c.DB.Save(t); err != nil {
 		c.errorf("Error saving tag: %s", err)
 		return failure
 	}
 
+	c.tags = append(c.tags, t)
+	sort.Sort(tag.ByName(c.tags))
+
 	return success
 }
 
+// existingTag looks for a tag, other than except, whose name matches
+// name regardless of case. c.tags is definitive, so it is enough to
+// check it for duplicates. It returns nil if there is no such tag.
+func (c *TagCommand) existingTag(name string, except *models.Tag) *models.Tag {
+	for _, existing := range c.tags {
+		if existing != except && strings.EqualFold(existing.Name, name) {
+			return existing
+		}
+	}
+
+	return nil
+}
+
 // printTagList prints the list of tags, with deadline and salience info
 // the list is numbered, and can be useful for tags that involve the user
 // looking at / selecting a particular tag (however use promptSelectTag
@@ -2,16 +2,18 @@ package command
 
 import (
 	"bytes"
+	"encoding/json"
 	"strings"
 	"testing"
 
 	"github.com/elos/data"
 	"github.com/elos/data/builtin/mem"
 	"github.com/elos/models"
+	xmodels "github.com/elos/x/models"
 	"github.com/mitchellh/cli"
 )
 
-// --- Testing Helper (newTestTag, newMockTagCommand) {{{
+// --- Testing Helper (newTestTag, newTestTaggedTask, newMockTagCommand) {{{
 
 func newTestTag(t *testing.T, db data.DB, u *models.User) *models.Tag {
 	tg := models.NewTag()
@@ -25,6 +27,19 @@ func newTestTag(t *testing.T, db data.DB, u *models.User) *models.Tag {
 	return tg
 }
 
+func newTestTaggedTask(t *testing.T, db data.DB, u *models.User, tags ...string) *xmodels.Task {
+	tsk := new(xmodels.Task)
+	tsk.SetID(db.NewID())
+	tsk.OwnerId = u.ID().String()
+	tsk.Tags = tags
+
+	if err := db.Save(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	return tsk
+}
+
 func newMockTagCommand(t *testing.T) (*cli.MockUi, data.DB, *models.User, *TagCommand) {
 	ui := new(cli.MockUi)
 	db := mem.NewDB()
@@ -54,6 +69,21 @@ func TestTagBasic(t *testing.T) {
 	}
 }
 
+// TestTagCloseError tests that an error closing the
+// query of the tags is reported along with its cause
+func TestTagCloseError(t *testing.T) {
+	ui, db, _, c := newMockTagCommand(t)
+	c.DB = closeErrDB{db}
+
+	if code := c.Run([]string{"list"}); code != failure {
+		t.Fatalf("Expected failure exit code, got %d", code)
+	}
+
+	if errput := ui.ErrorWriter.String(); !strings.Contains(errput, closeErr.Error()) {
+		t.Fatalf("Expected the error to contain the cause, got: %s", errput)
+	}
+}
+
 func TestTagInadequateInitialization(t *testing.T) {
 	// mock cli.Ui
 	ui := new(cli.MockUi)
@@ -149,6 +179,56 @@ func TestTagDelete(t *testing.T) {
 	}
 }
 
+// TestTagDeleteStripsTasks tests that the `delete` subcommand
+// removes the tag from the tasks which carry it
+func TestTagDeleteStripsTasks(t *testing.T) {
+	ui, db, user, c := newMockTagCommand(t)
+
+	tg := newTestTag(t, db, user)
+	tg.Name = "doomed"
+	if err := db.Save(tg); err != nil {
+		t.Fatal(err)
+	}
+
+	tsk := newTestTaggedTask(t, db, user, "doomed", "kept")
+
+	// first tag, and then confirm
+	ui.InputReader = bytes.NewBuffer([]byte("0\ny\n"))
+
+	t.Log("running: `elos tag delete`")
+	code := c.Run([]string{"delete"})
+	t.Log("command 'delete' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	if !strings.Contains(output, "Removed from 1 tasks") {
+		t.Fatalf("Output should have reported the number of tasks changed")
+	}
+
+	if err := db.PopulateByID(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("Task:\n%+v", tsk)
+
+	if len(tsk.Tags) != 1 || tsk.Tags[0] != "kept" {
+		t.Fatalf("Expected task's tags to be [kept], but were %v", tsk.Tags)
+	}
+}
+
 // --- }}}
 
 // --- `elos tag list` {{{
@@ -205,6 +285,175 @@ func TestTagList(t *testing.T) {
 	}
 }
 
+// TestTagListCounts test the `list -c` subcommand
+func TestTagListCounts(t *testing.T) {
+	ui, db, user, c := newMockTagCommand(t)
+
+	tag1 := newTestTag(t, db, user)
+	tag2 := newTestTag(t, db, user)
+	tag1.Name = "tag1"
+	if err := db.Save(tag1); err != nil {
+		t.Fatal(err)
+	}
+	tag2.Name = "tag2"
+	if err := db.Save(tag2); err != nil {
+		t.Fatal(err)
+	}
+
+	newTestTaggedTask(t, db, user, "tag2")
+	newTestTaggedTask(t, db, user, "tag1", "tag2")
+
+	t.Log("running: `elos tag list -c`")
+	code := c.Run([]string{"list", "-c"})
+	t.Log("command 'list -c' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// verify the counts, and that the most used tag comes first
+	if !strings.Contains(output, "1) tag2 [2]\n0) tag1 [1]") {
+		t.Fatalf("Output should have listed tag2 [2] before tag1 [1]")
+	}
+}
+
+// TestTagCountsPseudoTags tests that the pseudo-tags
+// elos keeps on tasks aren't counted as tags
+func TestTagCountsPseudoTags(t *testing.T) {
+	_, db, user, c := newMockTagCommand(t)
+
+	newTestTaggedTask(t, db, user, "tag1", priorityTagPrefix+"0", estimateTagPrefix+"2h0m0s")
+
+	counts, err := c.tagCounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if counts["tag1"] != 1 {
+		t.Fatalf("Expected tag1 to be counted once, got %d", counts["tag1"])
+	}
+
+	if len(counts) != 1 {
+		t.Fatalf("Expected only tag1 to be counted, got %v", counts)
+	}
+}
+
+// TestTagListJSON tests the `list --json` subcommand
+func TestTagListJSON(t *testing.T) {
+	ui, db, user, c := newMockTagCommand(t)
+
+	tag1 := newTestTag(t, db, user)
+	tag1.Name = "tag1"
+	if err := db.Save(tag1); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("running: `elos tag list --json`")
+	code := c.Run([]string{"list", "--json"})
+	t.Log("command `list --json` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	var tags []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(output), &tags); err != nil {
+		t.Fatalf("Output should be valid JSON: %s", err)
+	}
+
+	if len(tags) != 1 || tags[0].Name != "tag1" {
+		t.Fatalf("Expected the JSON to contain the tag 'tag1', got: %+v", tags)
+	}
+}
+
+// --- }}}
+
+// --- `elos tag merge` {{{
+
+// TestTagMerge test the `merge` subcommand
+func TestTagMerge(t *testing.T) {
+	ui, db, user, c := newMockTagCommand(t)
+
+	canonical := newTestTag(t, db, user)
+	canonical.Name = "work"
+	if err := db.Save(canonical); err != nil {
+		t.Fatal(err)
+	}
+	duplicate := newTestTag(t, db, user)
+	duplicate.Name = "Work"
+	if err := db.Save(duplicate); err != nil {
+		t.Fatal(err)
+	}
+
+	task1 := newTestTaggedTask(t, db, user, "Work")
+	task2 := newTestTaggedTask(t, db, user, "work", "Work")
+
+	input := strings.Join([]string{
+		"0", // merge from 'Work' (sorted first)
+		"1", // merge into 'work'
+		"y", // confirm
+	}, "\n")
+
+	ui.InputReader = bytes.NewBufferString(input)
+
+	t.Log("running: `elos tag merge`")
+	code := c.Run([]string{"merge"})
+	t.Log("command 'merge' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	for _, tsk := range []*xmodels.Task{task1, task2} {
+		if err := db.PopulateByID(tsk); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Logf("Task:\n%+v", tsk)
+
+		if len(tsk.Tags) != 1 || tsk.Tags[0] != "work" {
+			t.Fatalf("Expected task's tags to be [work], but were %v", tsk.Tags)
+		}
+	}
+
+	if err := db.PopulateByID(duplicate); err != data.ErrNotFound {
+		t.Fatal("Expected the duplicate tag to be deleted")
+	}
+}
+
 // --- }}}
 
 // --- `elos tag new` {{{
@@ -249,6 +498,60 @@ func TestTagNew(t *testing.T) {
 	}
 }
 
+// TestTagNewDuplicate tests that the `new` subcommand refuses to
+// create a tag whose name already exists
+func TestTagNewDuplicate(t *testing.T) {
+	ui, db, user, c := newMockTagCommand(t)
+
+	tg := newTestTag(t, db, user)
+	tg.Name = "work"
+	if err := db.Save(tg); err != nil {
+		t.Fatal(err)
+	}
+
+	// the same name, but in a different case
+	ui.InputReader = bytes.NewBufferString("WORK\n")
+
+	t.Log("running: `elos tag new`")
+	code := c.Run([]string{"new"})
+	t.Log("command 'new' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code.")
+	}
+
+	// verify the warning
+	if !strings.Contains(errput, "Tag already exists") {
+		t.Fatalf("Error output should have warned that the tag already exists")
+	}
+
+	iter, err := db.Query(models.TagKind).Select(data.AttrMap{
+		"owner_id": user.ID().String(),
+	}).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	for iter.Next(models.NewTag()) {
+		n++
+	}
+
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Fatalf("Expected exactly 1 tag, but found %d", n)
+	}
+}
+
 // --- }}}
 
 // --- `elos tag edit` {{{
@@ -310,6 +613,126 @@ func TestTagEdit(t *testing.T) {
 	}
 }
 
+// TestTagEditDuplicate tests that the `edit` subcommand refuses to
+// rename a tag to the name of another tag
+func TestTagEditDuplicate(t *testing.T) {
+	ui, db, u, c := newMockTagCommand(t)
+
+	for _, name := range []string{"home", "work"} {
+		tg := newTestTag(t, db, u)
+		tg.Name = name
+		if err := db.Save(tg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// rename "home", the first tag, to "work" in a different case
+	ui.InputReader = bytes.NewBufferString(strings.Join([]string{
+		"0",
+		"name",
+		"WORK",
+	}, "\n"))
+
+	t.Log("running: `elos tag edit`")
+	code := c.Run([]string{"edit"})
+	t.Log("command 'edit' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code.")
+	}
+
+	// verify the warning
+	if !strings.Contains(errput, "Tag already exists: 'work'") {
+		t.Fatalf("Error output should have warned that the tag already exists")
+	}
+
+	if !strings.Contains(errput, "elos tag merge") {
+		t.Fatalf("Error output should have pointed to 'elos tag merge'")
+	}
+
+	if strings.Contains(output, "Tag updated") {
+		t.Fatalf("Output should not say the tag was updated, got: %s", output)
+	}
+
+	iter, err := db.Query(models.TagKind).Select(data.AttrMap{
+		"owner_id": u.ID().String(),
+	}).Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(map[string]bool)
+	tg := models.NewTag()
+	for iter.Next(tg) {
+		names[tg.Name] = true
+	}
+
+	if err := iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !names["home"] || !names["work"] {
+		t.Fatalf("Expected the tags 'home' and 'work' to be unchanged, got: %v", names)
+	}
+}
+
+// TestTagEditRenamesTasks tests that renaming a tag with the
+// `edit` subcommand carries the new name to its tasks
+func TestTagEditRenamesTasks(t *testing.T) {
+	ui, db, u, c := newMockTagCommand(t)
+
+	tg := newTestTag(t, db, u)
+	tg.Name = "old_name"
+	if err := db.Save(tg); err != nil {
+		t.Fatal(err)
+	}
+
+	tsk := newTestTaggedTask(t, db, u, "old_name")
+
+	input := strings.Join([]string{
+		"0",
+		"name",
+		"new_name",
+	}, "\n")
+
+	ui.InputReader = bytes.NewBufferString(input)
+
+	t.Log("running: `elos tag edit`")
+	code := c.Run([]string{"edit"})
+	t.Log("command 'edit' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	if err := db.PopulateByID(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("Task:\n%+v", tsk)
+
+	if len(tsk.Tags) != 1 || tsk.Tags[0] != "new_name" {
+		t.Fatalf("Expected task's tags to be [new_name], but were %v", tsk.Tags)
+	}
+}
+
 // --- }}}
 
 // --- }}}
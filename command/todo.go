@@ -1,10 +1,16 @@
 package command
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
 	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/elos/data"
@@ -18,6 +24,64 @@ func dayEquivalent(t1 time.Time, t2 time.Time) bool {
 	return (t1.Year() == t2.Year() && t1.Month() == t2.Month() && t1.Day() == t2.Day())
 }
 
+// dateFormat is the format in which dates are displayed
+const dateFormat = "Mon Jan 2 15:04"
+
+// defaultNext is the number of upcoming tasks 'current --all' lists
+const defaultNext = 3
+
+// defaultCompletedWindow is how far back 'list -completed' looks
+const defaultCompletedWindow = "7d"
+
+// priorityTagPrefix prefixes the tag which holds the manual
+// priority of a task, i.e., "PRIORITY:0" is the first task
+const priorityTagPrefix = "PRIORITY:"
+
+// pseudoTagPrefixes prefix the tags which hold data elos keeps on a
+// task, rather than tags the user gave it. They are never displayed,
+// counted or changed as tags.
+var pseudoTagPrefixes = []string{priorityTagPrefix, estimateTagPrefix}
+
+// isPseudoTag indicates whether the tag name has one of the
+// pseudoTagPrefixes
+func isPseudoTag(name string) bool {
+	for _, prefix := range pseudoTagPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// userTags returns the task's tags, without its pseudo-tags
+func userTags(t *models.Task) []string {
+	tags := make([]string, 0, len(t.Tags))
+	for _, name := range t.Tags {
+		if !isPseudoTag(name) {
+			tags = append(tags, name)
+		}
+	}
+	return tags
+}
+
+// estimateTagPrefix prefixes the tag which holds the estimate of
+// how long a task will take, i.e., "EST:2h0m0s"
+const estimateTagPrefix = "EST:"
+
+// defaultCleanAge is how long ago a task must have been completed
+// for 'clean' to delete it
+const defaultCleanAge = "90d"
+
+// formatTime formats the time for display, in the given location.
+// A nil location is time.Local.
+func formatTime(t time.Time, loc *time.Location) string {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	return t.In(loc).Format(dateFormat)
+}
+
 // exit statuses
 const (
 	success = 0
@@ -41,6 +105,18 @@ type TodoCommand struct {
 	// It must be non-nil
 	data.DB
 
+	// Location is the location dates are entered and displayed in.
+	// If nil, time.Local is used
+	Location *time.Location
+
+	// Config is the elos configuration, in which tag weights are
+	// kept. If nil, no tags are weighted, and none can be.
+	Config *Config
+
+	// pageSize is the number of tasks printTaskList prints
+	// at a time, 0 prints them all at once
+	pageSize int
+
 	// The tasks of the user given by c.UserID
 	//
 	// During the lifecycle of the command, and assuming
@@ -65,20 +141,37 @@ Usage:
 	elos todo <subcommand>
 
 Subcommands:
+	abandon		stop a task without recording the time spent
+	clean [-older Nd]	delete the tasks completed over N days ago (90)
 	complete	complete a task
-	current		list current tasks
+	current (--all|--next n)	list current tasks (and the next 3 or n)
+	deadline [n when]	set the deadline of task n, i.e., 'deadline 2 tomorrow'
 	delete		delete a task
 	edit		edit a task
+	estimate [n duration]	estimate how long task n will take, i.e., 'estimate 1 2h'
 	fix		set new deadlines for passed tasks
-	goal		set a task as a goal
+	goal (-r)	set a task as a goal (remove)
 	goals		list task goals
-	list (-t)	list all your tasks (by tag)
+	import [-preserve-ids] [file]	import tasks, as output by 'list --json', from file (or stdin)
+	list (-t|--json|--page n)	list all your tasks (by tag, as JSON, n at a time)
+	list -sort manual|salience	list all your tasks in your manual order, or by salience
+	list -completed [Nd]	list the tasks you completed in the last N days (7)
+	move <n> <pos>	move task n to position pos of your manual order
 	new		create a new task
+	prereqs		list the incomplete prerequisites of a task
+	ready		list the tasks with no incomplete prerequisites
+	review [-period week|month]	review the tasks you completed in the last week (month)
+	salience	list your tasks by salience, with what it depends on
+	snooze [n duration]	push back the deadline of task n, i.e., 'snooze 1 3d'
 	start		start a task
 	stop		stop a task
 	suggest		have elos suggest a task
 	tag (-r)	tag a task (remove)
+	tag -all <tag>	tag all your tasks
+	tag -t <existing> <tag>	tag all your tasks carrying the existing tag
 	today		list the tasks you completed today
+	waiting		list the tasks with incomplete prerequisites
+	weight [tag w]	list tag weights, or weight the tag by w in 'suggest'
 `
 	return strings.TrimSpace(helpText)
 }
@@ -101,38 +194,74 @@ func (c *TodoCommand) Run(args []string) int {
 		return i
 	}
 
+	if needsTasks(args) {
+		if i := c.loadTasks(); i != success {
+			return i
+		}
+	}
+
 	switch args[0] {
+	case "abandon":
+		return c.runAbandon()
+	case "clean":
+		return c.runClean(args[1:])
 	case "co":
 	case "complete":
 		return c.runComplete()
 	case "cu":
 	case "current":
-		return c.runCurrent()
+		return c.runCurrent(args[1:])
 	case "d":
 	case "delete":
 		return c.runDelete()
+	case "deadline":
+		return c.runDeadline(args[1:])
 	case "e":
 	case "edit":
 		return c.runEdit()
+	case "estimate":
+		return c.runEstimate(args[1:])
 	case "f":
 	case "fix":
 		return c.runFix()
 	case "g":
 	case "goal":
+		if len(args) == 2 && args[1] == "-r" {
+			return c.runRemoveGoal()
+		}
+
 		return c.runGoal()
 	case "gs":
 	case "goals":
 		return c.runGoals()
+	case "import":
+		return c.runImport(args[1:])
 	case "l":
 	case "list":
 		if len(args) == 2 && args[1] == "-t" {
 			return c.runListTag()
 		}
 
-		return c.runList()
+		if len(args) > 1 && args[1] == "-completed" {
+			return c.runListCompleted(args[2:])
+		}
+
+		return c.runList(args[1:])
+	case "move":
+		return c.runMove(args[1:])
 	case "n":
 	case "new":
 		return c.runNew()
+	case "prereqs":
+		return c.runPrereqs()
+	case "ready":
+		return c.runReady()
+	case "review":
+		return c.runReview(args[1:])
+	case "salience":
+		return c.runSalience()
+	case "snooze":
+		return c.runSnooze(args[1:])
 	case "sta":
 	case "start":
 		return c.runStart()
@@ -148,10 +277,18 @@ func (c *TodoCommand) Run(args []string) int {
 			return c.runRemoveTag()
 		}
 
+		if len(args) > 1 && (args[1] == "-all" || args[1] == "-t") {
+			return c.runTagAll(args[1:])
+		}
+
 		return c.runTag()
 	case "to":
 	case "today":
 		return c.runToday()
+	case "waiting":
+		return c.runWaiting()
+	case "weight":
+		return c.runWeight(args[1:])
 	default:
 		c.UI.Output(c.Help())
 	}
@@ -162,12 +299,6 @@ func (c *TodoCommand) Run(args []string) int {
 // init performs some verification that the TodoCommand object
 // is valid (has a non-null database & UI and a user id).
 //
-// It loads all of the UserID's tasks into the tasks field of the
-// TodoCommand object.
-//
-// It loads all of the UserID's tags into the tags field of the
-// TodoCommand object.
-//
 // A 0 return value indicates success, a 1 indiciates failure. The
 // init command handles appropriate error printing the the UI.
 func (c *TodoCommand) init() int {
@@ -188,8 +319,31 @@ func (c *TodoCommand) init() int {
 		return failure
 	}
 
-	// Load the tasks
+	return success
+}
+
+// needsTasks indicates whether the subcommand, given with its
+// arguments, uses c.tasks, rather than querying the tasks it needs
+// itself, as 'list -completed' does.
+func needsTasks(args []string) bool {
+	switch args[0] {
+	case "clean", "import", "review", "to", "today", "weight":
+		return false
+	case "l", "list":
+		return !(len(args) > 1 && args[1] == "-completed")
+	default:
+		return true
+	}
+}
 
+// loadTasks loads all of the UserID's incomplete tasks into the
+// tasks field of the TodoCommand object, sorted by salience.
+//
+// The tasks are read one at a time, and only the incomplete ones
+// are kept, but all of those are needed in memory to be sorted.
+//
+// A 0 return value indicates success, a 1 indiciates failure.
+func (c *TodoCommand) loadTasks() int {
 	iter, err := c.DB.Query(data.Kind(models.Kind_TASK.String())).
 		Select(data.AttrMap{
 			"owner_id": c.UserID,
@@ -210,7 +364,7 @@ func (c *TodoCommand) init() int {
 	}
 
 	if err := iter.Close(); err != nil {
-		c.errorf("data retrieval: querying tasks")
+		c.errorf("data retrieval: querying tasks: %v", err)
 		return failure
 	}
 
@@ -228,6 +382,15 @@ func (c *TodoCommand) errorf(s string, values ...interface{}) {
 	c.UI.Error("[elos todo] Error: " + fmt.Sprintf(s, values...))
 }
 
+// location returns the location dates are entered and displayed in
+func (c *TodoCommand) location() *time.Location {
+	if c.Location == nil {
+		return time.Local
+	}
+
+	return c.Location
+}
+
 // removeTask removes the task at the given index.
 // You may use this for removing a task from memory after
 // it has been completed, or deleted.
@@ -235,6 +398,107 @@ func (c *TodoCommand) removeTask(index int) {
 	c.tasks = append(c.tasks[index:], c.tasks[index+1:]...)
 }
 
+// runAbandon runs the 'abandon' subcommand, which clears the in progress
+// state of a task specified by the user, without recording any time
+// for it. Use it for a task that was started but never stopped.
+func (c *TodoCommand) runAbandon() int {
+	if !c.anyInProgress() {
+		c.UI.Output("No tasks in progress")
+		return success
+	}
+
+	tsk, index := c.promptSelectTask(func(t *models.Task) bool {
+		return task.InProgress(t)
+	})
+	if index < 0 {
+		return selectStatus(index)
+	}
+
+	if !task.InProgress(tsk) || len(tsk.Stages) == 0 {
+		c.UI.Warn("Task is not in progress")
+		return success
+	}
+
+	// drop the stage which started the task
+	tsk.Stages = tsk.Stages[:len(tsk.Stages)-1]
+
+	if err := c.DB.Save(tsk); err != nil {
+		c.errorf("(subcommand abandon) Error: %s", err)
+		return failure
+	}
+
+	c.UI.Info(fmt.Sprintf("Abandoned '%s'", tsk.Name))
+	return success
+}
+
+// runClean runs the 'clean' subcommand, which deletes the user's tasks
+// completed more than N days ago (i.e., '-older 90d'), once the user
+// confirms. Completed tasks are otherwise kept forever.
+func (c *TodoCommand) runClean(args []string) int {
+	age, args, err := stringFlag(args, "older", defaultCleanAge)
+	if err != nil {
+		c.errorf("(subcommand clean) Error: %s", err)
+		return failure
+	}
+
+	if len(args) > 0 {
+		c.errorf("(subcommand clean) Error: unrecognized arguments %q", args)
+		return failure
+	}
+
+	before, err := parseSince(age, time.Now().In(c.location()))
+	if err != nil {
+		c.errorf("(subcommand clean) Error: %s", err)
+		return failure
+	}
+
+	iter, err := c.DB.Query(data.Kind(models.Kind_TASK.String())).Select(data.AttrMap{
+		"owner_id": c.UserID,
+	}).Execute()
+	if err != nil {
+		c.errorf("(subcommand clean) Error: querying tasks: %s", err)
+		return failure
+	}
+
+	old := make([]*models.Task, 0)
+	t := new(models.Task)
+	for iter.Next(t) {
+		if t.OwnerId == c.UserID && task.IsComplete(t) && t.CompletedAt.Time().Before(before) {
+			old = append(old, t)
+		}
+		t = new(models.Task)
+	}
+
+	if err := iter.Close(); err != nil {
+		c.errorf("(subcommand clean) Error: querying tasks: %s", err)
+		return failure
+	}
+
+	if len(old) == 0 {
+		c.UI.Output(fmt.Sprintf("You have no tasks completed before %s", formatTime(before, c.location())))
+		return success
+	}
+
+	if b, err := yesNo(c.UI, fmt.Sprintf("Delete %d tasks completed before %s?", len(old), formatTime(before, c.location()))); err != nil {
+		c.errorf("(subcommand clean) Error: %s", err)
+		return failure
+	} else if !b {
+		c.UI.Output("Cancelled")
+		return success
+	}
+
+	for _, t := range old {
+		if err := c.DB.Delete(t); err != nil {
+			c.errorf("(subcommand clean) Error: deleting '%s': %s", t.Name, err)
+			return failure
+		}
+	}
+
+	c.UI.Output(fmt.Sprintf("Deleted %d tasks", len(old)))
+
+	return success
+}
+
 // runComplete executes the "elos todo complete" command.
 //
 // Complete first prints a numbered list of the user's tasks.
@@ -245,7 +509,7 @@ func (c *TodoCommand) removeTask(index int) {
 func (c *TodoCommand) runComplete() int {
 	tsk, index := c.promptSelectTask()
 	if index < 0 {
-		return failure
+		return selectStatus(index)
 	}
 
 	task.StopAndComplete(tsk)
@@ -262,13 +526,42 @@ func (c *TodoCommand) runComplete() int {
 	c.UI.Info(fmt.Sprintf("Completed '%s'", tsk.Name))
 	c.UI.Info(fmt.Sprintf("Worked for %s total", task.TimeSpent(tsk)))
 
+	if est, ok := estimate(tsk); ok {
+		c.UI.Info(compareEstimate(est, task.TimeSpent(tsk)))
+	}
+
 	return success
 }
 
+// compareEstimate describes how the time spent on a task compares to
+// its estimate, i.e., "Estimated 2h0m0s, actually spent 3h0m0s (50% over)"
+func compareEstimate(est, spent time.Duration) string {
+	over := "over"
+	diff := spent - est
+	if diff < 0 {
+		over = "under"
+		diff = -diff
+	}
+
+	return fmt.Sprintf("Estimated %s, actually spent %s (%d%% %s)", est, spent, int64(diff*100/est), over)
+}
+
 // runCurrent executes the "elos todo current" command.
 //
-// Current prints the tasks that are currently in progress
-func (c *TodoCommand) runCurrent() int {
+// Current prints the tasks that are currently in progress. With
+// --all it also prints the next few tasks, by salience, which haven't
+// been started, and with --next N it prints the next N.
+func (c *TodoCommand) runCurrent(args []string) int {
+	next, err := intFlag(args, "next", 0)
+	if err != nil {
+		c.errorf("%s", err)
+		return failure
+	}
+
+	if next == 0 && hasFlag(args, "all") {
+		next = defaultNext
+	}
+
 	printedTask := false
 	c.printTaskList(func(t *models.Task) bool {
 		ok := task.InProgress(t)
@@ -284,6 +577,27 @@ func (c *TodoCommand) runCurrent() int {
 		c.UI.Output("You have no tasks in progress")
 	}
 
+	if next == 0 {
+		return success
+	}
+
+	// c.tasks is sorted by salience, so the first tasks
+	// which haven't been started are the next ones
+	c.UI.Output("Up next:")
+	printed := 0
+	c.printTaskList(func(t *models.Task) bool {
+		if printed == next || task.InProgress(t) {
+			return false
+		}
+
+		printed++
+		return true
+	})
+
+	if printed == 0 {
+		c.UI.Output("You have no tasks up next")
+	}
+
 	return success
 }
 
@@ -295,7 +609,7 @@ func (c *TodoCommand) runCurrent() int {
 func (c *TodoCommand) runDelete() int {
 	task, index := c.promptSelectTask()
 	if index < 0 {
-		return failure
+		return selectStatus(index)
 	}
 
 	err := c.DB.Delete(task)
@@ -316,7 +630,7 @@ func (c *TodoCommand) runDelete() int {
 func (c *TodoCommand) runEdit() int {
 	task, index := c.promptSelectTask()
 	if index < 0 {
-		return failure
+		return selectStatus(index)
 	}
 
 	bytes, err := json.MarshalIndent(task, "", "	")
@@ -333,13 +647,46 @@ func (c *TodoCommand) runEdit() int {
 
 	switch attributeToEdit {
 	case "completed_at":
-		task.CompletedAt, err = timestamp(dateInput(c.UI, "CompletedAt?"))
+		task.CompletedAt, err = c.promptValidDate("CompletedAt?", func(completed time.Time) error {
+			if completed.After(time.Now()) {
+				return fmt.Errorf("completed_at can't be in the future")
+			}
+
+			if !task.CreatedAt.IsZero() && completed.Before(task.CreatedAt.Time()) {
+				return fmt.Errorf("completed_at can't be before created_at")
+			}
+
+			return nil
+		})
 	case "created_at":
-		task.CreatedAt, err = timestamp(dateInput(c.UI, "CreatedAt?"))
+		task.CreatedAt, err = c.promptValidDate("CreatedAt?", func(created time.Time) error {
+			if !task.CompletedAt.IsZero() && created.After(task.CompletedAt.Time()) {
+				return fmt.Errorf("created_at can't be after completed_at")
+			}
+
+			return nil
+		})
 	case "deadline":
-		task.DeadlineAt, err = timestamp(dateInput(c.UI, "New deadline?"))
+		task.DeadlineAt, err = timestamp(dateInputIn(c.UI, "New deadline?", c.location()))
 	case "name":
 		task.Name, err = stringInput(c.UI, "New name?")
+	case "tags":
+		err = c.editTags(task)
+	case "prerequisites":
+		var add bool
+		if add, err = yesNo(c.UI, "Add a prerequisite? (otherwise remove one)"); err != nil {
+			break
+		} else if add {
+			err = c.addPrerequisite(task)
+			break
+		}
+
+		if len(task.PrerequisiteIds) == 0 {
+			c.UI.Warn("That task has no prerequisites")
+			return success
+		}
+
+		err = c.removePrerequisite(task)
 	default:
 		c.UI.Warn("That attribute is not recognized/supported")
 		return success
@@ -360,6 +707,134 @@ func (c *TodoCommand) runEdit() int {
 	return success
 }
 
+// promptValidDate prompts for a date until the date is valid according
+// to the validate function, explaining why each invalid date is invalid.
+func (c *TodoCommand) promptValidDate(text string, validate func(time.Time) error) (*models.Timestamp, error) {
+	for {
+		d, err := dateInputIn(c.UI, text, c.location())
+		if err != nil {
+			return nil, err
+		}
+
+		if err := validate(d); err != nil {
+			c.UI.Output(fmt.Sprintf("Shoot, %s, try again?", err))
+			continue
+		}
+
+		return models.TimestampFrom(d), nil
+	}
+}
+
+// editTags prompts the user to either add a tag to the task, or
+// remove one of the task's tags. The task is not saved.
+func (c *TodoCommand) editTags(t *models.Task) error {
+	c.UI.Output(fmt.Sprintf("Current tags: %s", strings.Join(userTags(t), ", ")))
+
+	remove, err := yesNo(c.UI, "Remove a tag? (otherwise add one)")
+	if err != nil {
+		return err
+	}
+
+	if !remove {
+		tg := c.promptSelectTag()
+		if tg == "" {
+			return fmt.Errorf("no tag given")
+		}
+
+		if isPseudoTag(tg) {
+			return fmt.Errorf("%q is reserved for elos", tg)
+		}
+
+		tag.Task(t, tg)
+		return nil
+	}
+
+	tg := c.promptSelectTagFromTask(t)
+	if tg == "" {
+		return fmt.Errorf("no tag selected")
+	}
+
+	tags := make([]string, 0, len(t.Tags))
+	for _, existing := range t.Tags {
+		if existing != tg {
+			tags = append(tags, existing)
+		}
+	}
+	t.Tags = tags
+
+	return nil
+}
+
+// addPrerequisite lists the current tasks, and adds the one the user
+// selects as a prerequisite of the task, unless it already depends on
+// the task, which would form a cycle. The task is not saved.
+func (c *TodoCommand) addPrerequisite(t *models.Task) error {
+	if len(c.tasks) == 0 {
+		c.UI.Warn("You have no tasks")
+		return nil
+	}
+
+	c.printTaskList()
+
+	i, err := intRangeInput(c.UI, "Which number?", 0, len(c.tasks)-1)
+	if err != nil {
+		return err
+	}
+
+	prereq := c.tasks[i]
+	if prereq.Id == t.Id {
+		c.UI.Warn("A task can't be its own prerequisite")
+		return nil
+	}
+
+	for _, id := range t.PrerequisiteIds {
+		if id == prereq.Id {
+			c.UI.Warn(fmt.Sprintf("'%s' is already a prerequisite", prereq.Name))
+			return nil
+		}
+	}
+
+	if cycle, err := c.dependsOn(prereq.Id, t.Id); err != nil {
+		return err
+	} else if cycle {
+		c.UI.Warn(fmt.Sprintf("'%s' already depends on this task, so it can't be a prerequisite", prereq.Name))
+		return nil
+	}
+
+	t.PrerequisiteIds = append(t.PrerequisiteIds, prereq.Id)
+	return nil
+}
+
+// removePrerequisite lists the task's prerequisites by name, and
+// removes the one the user selects. The task is not saved.
+func (c *TodoCommand) removePrerequisite(t *models.Task) error {
+	for i, id := range t.PrerequisiteIds {
+		prereq := &models.Task{Id: id}
+		var name string
+		switch err := c.DB.PopulateByID(prereq); err {
+		case nil:
+			name = prereq.Name
+		case data.ErrNotFound:
+			name = fmt.Sprintf("%s (deleted)", id)
+		default:
+			return err
+		}
+
+		c.UI.Output(fmt.Sprintf("%d) %s", i, name))
+	}
+
+	i, err := intRangeInput(c.UI, "Which prerequisite to remove?", 0, len(t.PrerequisiteIds)-1)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(t.PrerequisiteIds)-1)
+	ids = append(ids, t.PrerequisiteIds[:i]...)
+	t.PrerequisiteIds = append(ids, t.PrerequisiteIds[i+1:]...)
+
+	return nil
+}
+
 // runFix executes the "elos todo fix" command.
 //
 // Fix goes through the tasks whose deadline has passed and
@@ -372,22 +847,22 @@ func (c *TodoCommand) runFix() int {
 	// Only need the incomplete tasks, which are in c.tasks
 	for i, t := range c.tasks {
 		// If the deadline is in the future
-		if t.DeadlineAt.Time().IsZero() || t.DeadlineAt.Time().Local().After(time.Now()) {
+		if t.DeadlineAt.Time().IsZero() || t.DeadlineAt.Time().After(time.Now()) {
 			continue
 		}
 
 		neededFix = true
 
-		c.UI.Output(fmt.Sprintf("%d) %s %s", i, t.Name, t.DeadlineAt.Time().Format("Mon Jan 2 15:04")))
+		c.UI.Output(fmt.Sprintf("%d) %s %s", i, t.Name, formatTime(t.DeadlineAt.Time(), c.location())))
 
 	fix:
-		if t.DeadlineAt, inputError = timestamp(dateInput(c.UI, "New Deadline")); inputError != nil {
+		if t.DeadlineAt, inputError = timestamp(dateInputIn(c.UI, "New Deadline", c.location())); inputError != nil {
 			c.errorf("(subcommand fix) Input Error: %s", inputError)
 			return failure
 		}
 
-		if t.DeadlineAt.Time().Local().Before(time.Now()) {
-			c.UI.Output(fmt.Sprintf("Shoot, %s is still in the past, try again?", t.DeadlineAt.Time().Format("Mon Jan 2 15:04")))
+		if t.DeadlineAt.Time().Before(time.Now()) {
+			c.UI.Output(fmt.Sprintf("Shoot, %s is still in the past, try again?", formatTime(t.DeadlineAt.Time(), c.location())))
 			goto fix
 		}
 
@@ -395,7 +870,7 @@ func (c *TodoCommand) runFix() int {
 			c.errorf("(subcommand fix) Error: saving task: %s", err)
 			return failure
 		} else {
-			c.UI.Output(fmt.Sprintf("Deadline changed to %s", t.DeadlineAt.Time().Local().Format("Mon Jan 2 15:04")))
+			c.UI.Output(fmt.Sprintf("Deadline changed to %s", formatTime(t.DeadlineAt.Time(), c.location())))
 		}
 	}
 
@@ -406,12 +881,61 @@ func (c *TodoCommand) runFix() int {
 	return success
 }
 
+// runDeadline runs the 'deadline' subcommand, which sets the deadline
+// of a single task. The task index and the deadline may be given as
+// arguments, the deadline in any form parseDate understands. Without
+// arguments the task and deadline are prompted for.
+func (c *TodoCommand) runDeadline(args []string) int {
+	var (
+		t        *models.Task
+		deadline time.Time
+	)
+
+	switch len(args) {
+	case 0:
+		var index int
+		if t, index = c.promptSelectTask(); index < 0 {
+			return selectStatus(index)
+		}
+
+		var err error
+		if deadline, err = dateInputIn(c.UI, "New deadline?", c.location()); err != nil {
+			c.errorf("(subcommand deadline) Input Error: %s", err)
+			return failure
+		}
+	case 2:
+		index, err := strconv.Atoi(args[0])
+		if err != nil || index < 0 || index > len(c.tasks)-1 {
+			c.errorf("(subcommand deadline) Error: %q is not a valid index. Need a # in (0,...,%d)", args[0], len(c.tasks)-1)
+			return failure
+		}
+		t = c.tasks[index]
+
+		if deadline, err = parseDate(args[1], time.Now().In(c.location())); err != nil {
+			c.errorf("(subcommand deadline) Error: %s", err)
+			return failure
+		}
+	default:
+		c.errorf("(subcommand deadline) Error: expected a task number and a deadline, or nothing")
+		return failure
+	}
+
+	t.DeadlineAt = models.TimestampFrom(deadline)
+	if err := c.DB.Save(t); err != nil {
+		c.errorf("(subcommand deadline) Error: saving task: %s", err)
+		return failure
+	}
+
+	c.UI.Output(fmt.Sprintf("Deadline of '%s' changed to %s", t.Name, formatTime(deadline, c.location())))
+	return success
+}
+
 // runGoal runs the 'goal' subcommand, which adds this task to this
 // user's goals
 func (c *TodoCommand) runGoal() int {
 	task, index := c.promptSelectTask()
 	if index < 0 {
-		return failure
+		return selectStatus(index)
 	}
 
 	u := &models.User{Id: c.UserID}
@@ -420,7 +944,12 @@ func (c *TodoCommand) runGoal() int {
 		return failure
 	}
 
-	task.Tags = append(task.Tags, "GOAL")
+	if hasTag(task, "GOAL") {
+		c.UI.Warn(fmt.Sprintf("'%s' is already a goal", task.Name))
+		return success
+	}
+
+	tag.Task(task, "GOAL")
 
 	if err := c.DB.Save(task); err != nil {
 		c.errorf("saving task: %s", err)
@@ -430,85 +959,862 @@ func (c *TodoCommand) runGoal() int {
 	return success
 }
 
-// runGoals runs the 'goals' subcommand, which prints the user's goals
-func (c *TodoCommand) runGoals() int {
-	tasks, err := tag.TasksFor(c.DB, c.UserID, "GOAL")
-	if err != nil {
-		c.errorf("retrieving GOAL tasks: %s", err)
-		return failure
+// runRemoveGoal runs the 'goal' subcommand with the -r flag,
+// which removes a task from this user's goals
+func (c *TodoCommand) runRemoveGoal() int {
+	isGoal := func(t *models.Task) bool {
+		return hasTag(t, "GOAL")
 	}
 
-	taskIds := make(map[data.ID]bool)
-	for i := range tasks {
-		if !task.IsComplete(tasks[i]) {
-			taskIds[tasks[i].ID()] = true
+	anyGoals := false
+	for _, t := range c.tasks {
+		if isGoal(t) {
+			anyGoals = true
+			break
 		}
 	}
 
-	if len(taskIds) == 0 {
+	if !anyGoals {
 		c.UI.Output("No goals set. Use `elos todo goal` to add a goal.")
 		return success
 	}
 
-	c.UI.Output("Current Goals:")
-	c.printTaskList(func(t *models.Task) bool {
-		_, ok := taskIds[t.ID()]
-		return ok
-	})
+	c.UI.Output("Select which goal to remove")
+	tsk, index := c.promptSelectTask(isGoal)
+	if index < 0 {
+		return selectStatus(index)
+	}
 
-	return success
-}
+	if !isGoal(tsk) {
+		c.UI.Warn("Task is not a goal")
+		return success
+	}
+
+	tags := make([]string, 0, len(tsk.Tags))
+	for _, t := range tsk.Tags {
+		if t != "GOAL" {
+			tags = append(tags, t)
+		}
+	}
+	tsk.Tags = tags
+
+	if err := c.DB.Save(tsk); err != nil {
+		c.errorf("saving task: %s", err)
+		return failure
+	}
+
+	c.UI.Output(fmt.Sprintf("Removed '%s' from your goals", tsk.Name))
+
+	return success
+}
+
+// runGoals runs the 'goals' subcommand, which prints the user's goals
+func (c *TodoCommand) runGoals() int {
+	tasks, err := tag.TasksFor(c.DB, c.UserID, "GOAL")
+	if err != nil {
+		c.errorf("retrieving GOAL tasks: %s", err)
+		return failure
+	}
+
+	taskIds := make(map[data.ID]bool)
+	for i := range tasks {
+		if !task.IsComplete(tasks[i]) {
+			taskIds[tasks[i].ID()] = true
+		}
+	}
+
+	if len(taskIds) == 0 {
+		c.UI.Output("No goals set. Use `elos todo goal` to add a goal.")
+		return success
+	}
+
+	c.UI.Output("Current Goals:")
+	c.printTaskList(func(t *models.Task) bool {
+		_, ok := taskIds[t.ID()]
+		return ok
+	})
+
+	return success
+}
+
+// runImport runs the 'import' subcommand, which recreates the tasks
+// in a dump, of the form output by 'list --json', read from the file
+// given as an argument or from stdin.
+//
+// The tasks are given new ids, unless -preserve-ids is given, and are
+// owned by the current user. A task whose id already exists is skipped.
+func (c *TodoCommand) runImport(args []string) int {
+	format, args, err := stringFlag(args, "format", "json")
+	if err != nil {
+		c.errorf("(subcommand import) Error: %s", err)
+		return failure
+	}
+
+	if format != "json" {
+		c.errorf("(subcommand import) Error: unsupported format %q, only json is supported", format)
+		return failure
+	}
+
+	preserveIDs := hasFlag(args, "preserve-ids")
+
+	files := make([]string, 0, 1)
+	for _, a := range args {
+		if !strings.HasPrefix(a, "-") {
+			files = append(files, a)
+		}
+	}
+
+	if len(files) > 1 {
+		c.errorf("(subcommand import) Error: expected at most one file, got %d", len(files))
+		return failure
+	}
+
+	var in io.ReadCloser = ioutil.NopCloser(os.Stdin)
+	if len(files) == 1 {
+		if in, err = os.Open(files[0]); err != nil {
+			c.errorf("(subcommand import) Error: %s", err)
+			return failure
+		}
+	}
+
+	var tasks []*models.Task
+	err = json.NewDecoder(in).Decode(&tasks)
+	in.Close()
+	if err != nil {
+		c.errorf("(subcommand import) Error: decoding tasks: %s", err)
+		return failure
+	}
+
+	// ids maps the id of each imported task to its new id, so
+	// that prerequisites within the dump can be carried over
+	ids := make(map[string]string)
+	imported := make([]*models.Task, 0, len(tasks))
+	for i, t := range tasks {
+		if t == nil || t.Name == "" {
+			c.UI.Warn(fmt.Sprintf("Skipping task %d, it has no name", i))
+			continue
+		}
+
+		if preserveIDs {
+			if t.Id == "" {
+				c.UI.Warn(fmt.Sprintf("Skipping '%s', it has no id to preserve", t.Name))
+				continue
+			}
+
+			if _, err := c.DB.ParseID(t.Id); err != nil {
+				c.UI.Warn(fmt.Sprintf("Skipping '%s', %q is not a valid id", t.Name, t.Id))
+				continue
+			}
+		}
+
+		if t.Id != "" {
+			switch err := c.DB.PopulateByID(&models.Task{Id: t.Id}); err {
+			case nil:
+				c.UI.Warn(fmt.Sprintf("Skipping '%s', it already exists", t.Name))
+				continue
+			case data.ErrNotFound:
+			default:
+				c.errorf("(subcommand import) Error: %s", err)
+				return failure
+			}
+		}
+
+		old := t.Id
+		if !preserveIDs {
+			t.SetID(c.DB.NewID())
+		}
+		if old != "" {
+			ids[old] = t.Id
+		}
+
+		t.OwnerId = c.UserID
+		imported = append(imported, t)
+	}
+
+	for _, t := range imported {
+		for i, id := range t.PrerequisiteIds {
+			if newID, ok := ids[id]; ok {
+				t.PrerequisiteIds[i] = newID
+			}
+		}
+
+		t.UpdatedAt = models.TimestampFrom(time.Now())
+		if err := c.DB.Save(t); err != nil {
+			c.errorf("(subcommand import) Error: saving '%s': %s", t.Name, err)
+			return failure
+		}
+	}
+
+	c.UI.Output(fmt.Sprintf("Imported %d tasks", len(imported)))
+	return success
+}
+
+// runList runs the 'list' subcommand. It prints a list of the
+// tasks cached in c.tasks.
+func (c *TodoCommand) runList(args []string) int {
+	order, args, err := stringFlag(args, "sort", "salience")
+	if err != nil {
+		c.errorf("(subcommand list) Error: %s", err)
+		return failure
+	}
+
+	switch order {
+	case "salience":
+		// c.tasks are sorted by salience
+	case "manual":
+		// tasks without a manual priority remain in order of salience
+		sort.Stable(byPriority(c.tasks))
+	default:
+		c.errorf("(subcommand list) Error: unknown sort %q, expected manual or salience", order)
+		return failure
+	}
+
+	if hasFlag(args, "json") {
+		if err := outputJSON(c.UI, c.tasks); err != nil {
+			c.errorf("(subcommand list) Error: %s", err)
+			return failure
+		}
+		return success
+	}
+
+	size, err := pageFlag(args)
+	if err != nil {
+		c.errorf("(subcommand list) Error: %s", err)
+		return failure
+	}
+	c.pageSize = size
 
-// runList runs the 'list' subcommand. It prints a list of the
-// tasks cached in c.tasks.
-func (c *TodoCommand) runList() int {
 	c.UI.Output("Todos:")
 	c.printTaskList()
 	return success
 }
 
-// runListTag runs the 'list -t' subcommand. It prints a list of the
-// tasks cached in c.tasks according to the specified tag.
-func (c *TodoCommand) runListTag() int {
-	tg := c.promptSelectTag()
-	if tg == "" {
+// runListCompleted runs the 'list -completed' subcommand. It prints the
+// tasks completed in the last N days (i.e., "3d"), or since a date, in
+// the order they were completed, with the time spent on each.
+//
+// c.tasks holds only incomplete tasks, so the tasks are queried anew.
+func (c *TodoCommand) runListCompleted(args []string) int {
+	window := defaultCompletedWindow
+	switch len(args) {
+	case 0:
+	case 1:
+		window = args[0]
+	default:
+		c.errorf("(subcommand list) Error: expected at most one window, i.e., 'list -completed 3d'")
+		return failure
+	}
+
+	since, err := parseSince(window, time.Now().In(c.location()))
+	if err != nil {
+		c.errorf("(subcommand list) Error: %s", err)
+		return failure
+	}
+
+	completed, err := c.completedSince(since)
+	if err != nil {
+		c.errorf("(subcommand list) Error: querying tasks: %s", err)
+		return failure
+	}
+
+	if len(completed) == 0 {
+		c.UI.Output(fmt.Sprintf("You have completed no tasks since %s", formatTime(since, c.location())))
+		return success
+	}
+
+	c.UI.Output(fmt.Sprintf("Completed since %s:", formatTime(since, c.location())))
+	for i, t := range completed {
+		c.UI.Output(fmt.Sprintf("%d)%s (%s)\n\tTime Spent:%s", i, String(t), formatTime(t.CompletedAt.Time(), c.location()), task.TimeSpent(t)))
+	}
+
+	return success
+}
+
+// completedSince queries the user's tasks completed since the
+// given time, and returns them in the order they were completed.
+func (c *TodoCommand) completedSince(since time.Time) ([]*models.Task, error) {
+	iter, err := c.DB.Query(data.Kind(models.Kind_TASK.String())).Select(data.AttrMap{
+		"owner_id": c.UserID,
+	}).Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	completed := make([]*models.Task, 0)
+	t := new(models.Task)
+	for iter.Next(t) {
+		if task.IsComplete(t) && !t.CompletedAt.Time().Before(since) {
+			completed = append(completed, t)
+		}
+
+		// always a new task, iter.Next reuses the one it is given
+		t = new(models.Task)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	sort.Sort(byCompletedAt(completed))
+
+	return completed, nil
+}
+
+// byCompletedAt sorts tasks by the time they
+// were completed at, earliest first
+type byCompletedAt []*models.Task
+
+func (b byCompletedAt) Len() int {
+	return len(b)
+}
+
+func (b byCompletedAt) Less(i, j int) bool {
+	return b[i].CompletedAt.Time().Before(b[j].CompletedAt.Time())
+}
+
+func (b byCompletedAt) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
+// runListTag runs the 'list -t' subcommand. It prints a list of the
+// tasks cached in c.tasks according to the specified tag.
+func (c *TodoCommand) runListTag() int {
+	tg := c.promptSelectTag()
+	if tg == "" {
+		return success
+	}
+
+	tasks, err := tag.TasksFor(c.DB, c.UserID, tg)
+	if err != nil {
+		c.errorf("retrieving tasks: %s", err)
+		return failure
+	}
+
+	ids := make(map[data.ID]bool)
+	for _, t := range tasks {
+		ids[t.ID()] = true
+	}
+
+	c.UI.Output(fmt.Sprintf("%s Tasks:", tg))
+	c.printTaskList(func(t *models.Task) bool {
+		_, ok := ids[t.ID()]
+		return ok
+	})
+	return success
+}
+
+// runMove runs the 'move' subcommand, which moves a task to a position
+// in the user's manual order, i.e., 'move 3 0' makes task 3 the first.
+// The tasks of the manual order, and their positions, are kept as
+// PRIORITY tags, and the tasks after the position are renumbered.
+func (c *TodoCommand) runMove(args []string) int {
+	if len(args) != 2 {
+		c.errorf("(subcommand move) Error: expected a task number and a position")
+		return failure
+	}
+
+	index, err := strconv.Atoi(args[0])
+	if err != nil || index < 0 || index > len(c.tasks)-1 {
+		c.errorf("(subcommand move) Error: %q is not a valid index. Need a # in (0,...,%d)", args[0], len(c.tasks)-1)
+		return failure
+	}
+	t := c.tasks[index]
+
+	pos, err := strconv.Atoi(args[1])
+	if err != nil || pos < 0 {
+		c.errorf("(subcommand move) Error: %q is not a valid position", args[1])
+		return failure
+	}
+
+	queue := make([]*models.Task, 0)
+	for _, other := range c.tasks {
+		if _, ok := priority(other); ok && other != t {
+			queue = append(queue, other)
+		}
+	}
+	sort.Stable(byPriority(queue))
+
+	if pos > len(queue) {
+		pos = len(queue)
+	}
+	queue = append(queue[:pos], append([]*models.Task{t}, queue[pos:]...)...)
+
+	for i, q := range queue {
+		if n, ok := priority(q); ok && n == i {
+			continue
+		}
+
+		setPriority(q, i)
+		if err := c.DB.Save(q); err != nil {
+			c.errorf("(subcommand move) Error: saving task: %s", err)
+			return failure
+		}
+	}
+
+	c.UI.Output(fmt.Sprintf("Moved '%s' to position %d", t.Name, pos))
+	return success
+}
+
+// priority returns the manual priority of the task,
+// and whether it has one
+func priority(t *models.Task) (int, bool) {
+	for _, tg := range t.Tags {
+		if !strings.HasPrefix(tg, priorityTagPrefix) {
+			continue
+		}
+
+		if n, err := strconv.Atoi(strings.TrimPrefix(tg, priorityTagPrefix)); err == nil {
+			return n, true
+		}
+	}
+
+	return 0, false
+}
+
+// setPriority replaces the manual priority of the task with n.
+// The task is not saved.
+func setPriority(t *models.Task, n int) {
+	tags := make([]string, 0, len(t.Tags)+1)
+	for _, tg := range t.Tags {
+		if !strings.HasPrefix(tg, priorityTagPrefix) {
+			tags = append(tags, tg)
+		}
+	}
+	t.Tags = append(tags, priorityTagPrefix+strconv.Itoa(n))
+}
+
+// byPriority sorts tasks by their manual priority,
+// the tasks without one last
+type byPriority []*models.Task
+
+func (b byPriority) Len() int {
+	return len(b)
+}
+
+func (b byPriority) Less(i, j int) bool {
+	pi, iok := priority(b[i])
+	pj, jok := priority(b[j])
+	if iok != jok {
+		return iok
+	}
+
+	return pi < pj
+}
+
+func (b byPriority) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
+// runNew runs the 'new' subcommand, which prompts the user to
+// create a new task.
+func (c *TodoCommand) runNew() int {
+	t, err := c.promptNewTask()
+	if err != nil {
+		c.errorf("(subcommand  new): Error: %s", err)
+		return failure
+	}
+
+	c.UI.Output("Summary:")
+	if err := c.printTaskTree(t, 0); err != nil {
+		c.errorf("(subcommand new) Error: %s", err)
+		return failure
+	}
+
+	return success
+}
+
+// printTaskTree prints the task, with its deadline, and then the tree
+// of its prerequisites, each indented one tab further than the task
+// it is a prerequisite of.
+func (c *TodoCommand) printTaskTree(t *models.Task, depth int) error {
+	deadline := ""
+	if !t.DeadlineAt.IsZero() {
+		deadline = fmt.Sprintf(" (%s)", formatTime(t.DeadlineAt.Time(), c.location()))
+	}
+	c.UI.Output(fmt.Sprintf("%s* %s%s", strings.Repeat("\t", depth), t.Name, deadline))
+
+	for _, id := range t.PrerequisiteIds {
+		prereq := &models.Task{Id: id}
+		switch err := c.DB.PopulateByID(prereq); err {
+		case nil:
+			if err := c.printTaskTree(prereq, depth+1); err != nil {
+				return err
+			}
+		case data.ErrNotFound:
+			c.UI.Output(fmt.Sprintf("%s* %s (unknown task)", strings.Repeat("\t", depth+1), id))
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runPrereqs runs the 'prereqs' subcommand, which lists the incomplete
+// prerequisites of a task specified by the user, i.e., what is blocking it.
+func (c *TodoCommand) runPrereqs() int {
+	tsk, index := c.promptSelectTask()
+	if index < 0 {
+		return selectStatus(index)
+	}
+
+	blocked := false
+	for _, id := range tsk.PrerequisiteIds {
+		prereq := &models.Task{Id: id}
+		switch err := c.DB.PopulateByID(prereq); err {
+		case nil:
+			if task.IsComplete(prereq) {
+				continue
+			}
+
+			c.UI.Output(fmt.Sprintf("* %s", prereq.Name))
+		case data.ErrNotFound:
+			c.UI.Output(fmt.Sprintf("* %s (unknown task)", id))
+		default:
+			c.errorf("(subcommand prereqs) Error: retrieving prerequisite: %s", err)
+			return failure
+		}
+
+		blocked = true
+	}
+
+	if !blocked {
+		c.UI.Output(fmt.Sprintf("'%s' is ready to start", tsk.Name))
+	}
+
+	return success
+}
+
+// runReady runs the 'ready' subcommand, which lists the tasks which
+// can be started now, those with no incomplete prerequisites.
+func (c *TodoCommand) runReady() int {
+	blocked, err := c.blockedTasks()
+	if err != nil {
+		c.errorf("(subcommand ready) Error: retrieving prerequisites: %s", err)
+		return failure
+	}
+
+	if len(blocked) == len(c.tasks) {
+		c.UI.Output("None of your tasks are ready")
+		return success
+	}
+
+	c.UI.Output("Ready:")
+	c.printTaskList(func(t *models.Task) bool {
+		return !blocked[t.Id]
+	})
+
+	return success
+}
+
+// runWaiting runs the 'waiting' subcommand, which lists the tasks which
+// are blocked, those with at least one incomplete prerequisite.
+func (c *TodoCommand) runWaiting() int {
+	blocked, err := c.blockedTasks()
+	if err != nil {
+		c.errorf("(subcommand waiting) Error: retrieving prerequisites: %s", err)
+		return failure
+	}
+
+	if len(blocked) == 0 {
+		c.UI.Output("None of your tasks are waiting")
+		return success
+	}
+
+	c.UI.Output("Waiting:")
+	c.printTaskList(func(t *models.Task) bool {
+		return blocked[t.Id]
+	})
+
+	return success
+}
+
+// blockedTasks returns the set of the ids of the tasks, of c.tasks,
+// which have an incomplete prerequisite. As with 'prereqs', a
+// prerequisite which can't be found is considered incomplete.
+//
+// Each prerequisite is looked up at most once, and those which
+// are in c.tasks, which are all incomplete, aren't looked up.
+func (c *TodoCommand) blockedTasks() (map[string]bool, error) {
+	complete := make(map[string]bool, len(c.tasks))
+	for _, t := range c.tasks {
+		complete[t.Id] = false
+	}
+
+	blocked := make(map[string]bool)
+	for _, t := range c.tasks {
+		for _, id := range t.PrerequisiteIds {
+			done, ok := complete[id]
+			if !ok {
+				prereq := &models.Task{Id: id}
+				switch err := c.DB.PopulateByID(prereq); err {
+				case nil:
+					done = task.IsComplete(prereq)
+				case data.ErrNotFound:
+					done = false
+				default:
+					return nil, err
+				}
+				complete[id] = done
+			}
+
+			if !done {
+				blocked[t.Id] = true
+				break
+			}
+		}
+	}
+
+	return blocked, nil
+}
+
+// runReview runs the 'review' subcommand, which lists the tasks
+// completed in the last week, or with '-period month', the last month.
+func (c *TodoCommand) runReview(args []string) int {
+	period, args, err := stringFlag(args, "period", "week")
+	if err != nil {
+		c.errorf("(subcommand review) Error: %s", err)
+		return failure
+	}
+
+	if len(args) > 0 {
+		c.errorf("(subcommand review) Error: unrecognized arguments %q", args)
+		return failure
+	}
+
+	now := time.Now().In(c.location())
+	var since time.Time
+	switch period {
+	case "week":
+		since = now.AddDate(0, 0, -7)
+	case "month":
+		since = now.AddDate(0, -1, 0)
+	default:
+		c.errorf("(subcommand review) Error: unknown period %q, expected week or month", period)
+		return failure
+	}
+
+	completed, err := c.completedSince(since)
+	if err != nil {
+		c.errorf("(subcommand review) Error: querying tasks: %s", err)
+		return failure
+	}
+
+	if len(completed) == 0 {
+		c.UI.Output(fmt.Sprintf("No tasks completed in the last %s", period))
+		return success
+	}
+
+	c.UI.Output(fmt.Sprintf("Completed in the last %s:", period))
+	for _, t := range completed {
+		c.UI.Output(fmt.Sprintf("\t* %s [%s]", t.Name, t.CompletedAt.Time().In(c.location()).Format("Mon Jan 2")))
+	}
+
+	return success
+}
+
+// bySalience sorts the indices of tasks by the salience
+// of the task at each index, most salient first
+type bySalience struct {
+	indices  []int
+	salience []float64
+}
+
+func (s bySalience) Len() int { return len(s.indices) }
+func (s bySalience) Swap(i, j int) {
+	s.indices[i], s.indices[j] = s.indices[j], s.indices[i]
+	s.salience[i], s.salience[j] = s.salience[j], s.salience[i]
+}
+func (s bySalience) Less(i, j int) bool { return s.salience[i] > s.salience[j] }
+
+// runSalience runs the 'salience' subcommand, which prints a table of
+// the user's tasks, most salient first, alongside the deadline, tags and
+// prerequisites the salience of each is computed from. It is read-only,
+// and useful for understanding the order of 'list' and 'suggest'.
+func (c *TodoCommand) runSalience() int {
+	if len(c.tasks) == 0 {
+		c.UI.Output("You have no tasks")
 		return success
 	}
 
-	tasks, err := tag.TasksFor(c.DB, c.UserID, tg)
-	if err != nil {
-		c.errorf("retrieving tasks: %s", err)
+	s := bySalience{
+		indices:  make([]int, len(c.tasks)),
+		salience: make([]float64, len(c.tasks)),
+	}
+	for i, t := range c.tasks {
+		s.indices[i] = i
+		s.salience[i] = task.Salience(t)
+	}
+	sort.Sort(s)
+
+	table := new(bytes.Buffer)
+	w := tabwriter.NewWriter(table, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tSALIENCE\tDEADLINE\tTAGS\tPREREQS\tNAME")
+
+	for j, i := range s.indices {
+		t := c.tasks[i]
+
+		deadline := "-"
+		if !t.DeadlineAt.IsZero() {
+			deadline = formatTime(t.DeadlineAt.Time(), c.location())
+		}
+
+		tags := "-"
+		if names := userTags(t); len(names) > 0 {
+			tags = strings.Join(names, ",")
+		}
+
+		fmt.Fprintf(w, "%d\t%f\t%s\t%s\t%d\t%s\n", i, s.salience[j], deadline, tags, len(t.PrerequisiteIds), t.Name)
+	}
+
+	w.Flush()
+	c.UI.Output(strings.TrimSuffix(table.String(), "\n"))
+
+	return success
+}
+
+// runSnooze runs the 'snooze' subcommand, which pushes back the
+// deadline of a single task by a duration, or, if the task has no
+// deadline, sets it to the duration from now. The task index and the
+// duration may be given as arguments, the duration in any form
+// parseDuration understands. Without arguments they are prompted for.
+func (c *TodoCommand) runSnooze(args []string) int {
+	var (
+		t *models.Task
+		d time.Duration
+	)
+
+	switch len(args) {
+	case 0:
+		var index int
+		if t, index = c.promptSelectTask(); index < 0 {
+			return selectStatus(index)
+		}
+
+		var err error
+		if d, err = durationInput(c.UI, "Snooze for how long?"); err != nil {
+			c.errorf("(subcommand snooze) Input Error: %s", err)
+			return failure
+		}
+	case 2:
+		index, err := strconv.Atoi(args[0])
+		if err != nil || index < 0 || index > len(c.tasks)-1 {
+			c.errorf("(subcommand snooze) Error: %q is not a valid index. Need a # in (0,...,%d)", args[0], len(c.tasks)-1)
+			return failure
+		}
+		t = c.tasks[index]
+
+		if d, err = parseDuration(args[1]); err != nil {
+			c.errorf("(subcommand snooze) Error: %s", err)
+			return failure
+		}
+	default:
+		c.errorf("(subcommand snooze) Error: expected a task number and a duration, or nothing")
 		return failure
 	}
 
-	ids := make(map[data.ID]bool)
-	for _, t := range tasks {
-		ids[t.ID()] = true
+	deadline := time.Now()
+	if !t.DeadlineAt.IsZero() {
+		deadline = t.DeadlineAt.Time()
 	}
+	deadline = deadline.Add(d)
 
-	c.UI.Output(fmt.Sprintf("%s Tasks:", tg))
-	c.printTaskList(func(t *models.Task) bool {
-		_, ok := ids[t.ID()]
-		return ok
-	})
+	t.DeadlineAt = models.TimestampFrom(deadline)
+	if err := c.DB.Save(t); err != nil {
+		c.errorf("(subcommand snooze) Error: saving task: %s", err)
+		return failure
+	}
+
+	c.UI.Output(fmt.Sprintf("Snoozed '%s' until %s", t.Name, formatTime(deadline, c.location())))
 	return success
 }
 
-// runNew runs the 'new' subcommand, which prompts the user to
-// create a new task.
-func (c *TodoCommand) runNew() int {
-	_, err := c.promptNewTask()
-	if err != nil {
-		c.errorf("(subcommand  new): Error: %s", err)
+// runEstimate runs the 'estimate' subcommand, which records how long a
+// single task is expected to take. The task index and the estimate may
+// be given as arguments, the estimate in any form parseDuration
+// understands. Without arguments they are prompted for.
+func (c *TodoCommand) runEstimate(args []string) int {
+	var (
+		t *models.Task
+		d time.Duration
+	)
+
+	switch len(args) {
+	case 0:
+		var index int
+		if t, index = c.promptSelectTask(); index < 0 {
+			return selectStatus(index)
+		}
+
+		var err error
+		if d, err = durationInput(c.UI, "How long will it take?"); err != nil {
+			c.errorf("(subcommand estimate) Input Error: %s", err)
+			return failure
+		}
+	case 2:
+		index, err := strconv.Atoi(args[0])
+		if err != nil || index < 0 || index > len(c.tasks)-1 {
+			c.errorf("(subcommand estimate) Error: %q is not a valid index. Need a # in (0,...,%d)", args[0], len(c.tasks)-1)
+			return failure
+		}
+		t = c.tasks[index]
+
+		if d, err = parseDuration(args[1]); err != nil {
+			c.errorf("(subcommand estimate) Error: %s", err)
+			return failure
+		}
+	default:
+		c.errorf("(subcommand estimate) Error: expected a task number and a duration, or nothing")
+		return failure
+	}
+
+	if d <= 0 {
+		c.errorf("(subcommand estimate) Error: the estimate must be positive")
+		return failure
+	}
+
+	setEstimate(t, d)
+	if err := c.DB.Save(t); err != nil {
+		c.errorf("(subcommand estimate) Error: saving task: %s", err)
 		return failure
 	}
+
+	c.UI.Output(fmt.Sprintf("Estimated '%s' at %s", t.Name, d))
 	return success
 }
 
+// estimate returns how long the task is expected to take,
+// and whether it has an estimate
+func estimate(t *models.Task) (time.Duration, bool) {
+	for _, tg := range t.Tags {
+		if !strings.HasPrefix(tg, estimateTagPrefix) {
+			continue
+		}
+
+		if d, err := time.ParseDuration(strings.TrimPrefix(tg, estimateTagPrefix)); err == nil && d > 0 {
+			return d, true
+		}
+	}
+
+	return 0, false
+}
+
+// setEstimate replaces the estimate of the task with d.
+// The task is not saved.
+func setEstimate(t *models.Task, d time.Duration) {
+	tags := make([]string, 0, len(t.Tags)+1)
+	for _, tg := range t.Tags {
+		if !strings.HasPrefix(tg, estimateTagPrefix) {
+			tags = append(tags, tg)
+		}
+	}
+	t.Tags = append(tags, estimateTagPrefix+d.String())
+}
+
 func (c *TodoCommand) runStart() int {
 	tsk, index := c.promptSelectTask()
 	if index < 0 {
-		return failure
+		return selectStatus(index)
 	}
 
 	// task.Start(tsk) is idempotent, and simply won't
@@ -535,15 +1841,7 @@ func (c *TodoCommand) runStart() int {
 // runStop runs the 'stop' command, which stops a task specified
 // by the user.
 func (c *TodoCommand) runStop() int {
-	anyInProgress := false
-	for _, t := range c.tasks {
-		if task.InProgress(t) {
-			anyInProgress = true
-			break
-		}
-	}
-
-	if !anyInProgress {
+	if !c.anyInProgress() {
 		c.UI.Output("No tasks in progress")
 		return success
 	}
@@ -553,7 +1851,7 @@ func (c *TodoCommand) runStop() int {
 	})
 
 	if index < 0 {
-		return failure
+		return selectStatus(index)
 	}
 
 	// task.Stop(tsk) is idempotent, meaning it won't stop the task
@@ -571,8 +1869,17 @@ func (c *TodoCommand) runStop() int {
 		return failure
 	}
 
+	// a corrupted task may not have the two stages which bound
+	// this session, so fall back to the total time spent on it
+	n := len(tsk.Stages)
+	if n < 2 {
+		c.UI.Warn("Couldn't compute the time of that session")
+		c.UI.Info(fmt.Sprintf("You have worked for %s total", task.TimeSpent(tsk)))
+		return success
+	}
+
 	// Info, i.e., "You worked for 20m that time"
-	c.UI.Info(fmt.Sprintf("You worked for %s that time", tsk.Stages[len(tsk.Stages)-1].Time().Sub(tsk.Stages[len(tsk.Stages)-2].Time())))
+	c.UI.Info(fmt.Sprintf("You worked for %s that time", tsk.Stages[n-1].Time().Sub(tsk.Stages[n-2].Time())))
 	return success
 }
 
@@ -584,10 +1891,10 @@ func (c *TodoCommand) runSuggest() int {
 		return success
 	}
 
-	suggested := task.NewGraph(c.tasks).Suggest()
+	suggested := c.suggest()
 
 	tagNames := ""
-	tags := suggested.Tags
+	tags := userTags(suggested)
 	for _, t := range tags {
 		tagNames += fmt.Sprintf("[%s]", t)
 	}
@@ -618,7 +1925,7 @@ func (c *TodoCommand) runSuggest() int {
 func (c *TodoCommand) runTag() int {
 	tsk, index := c.promptSelectTask()
 	if index < 0 {
-		return failure
+		return selectStatus(index)
 	}
 
 	c.UI.Output("Which tag to add?")
@@ -627,6 +1934,11 @@ func (c *TodoCommand) runTag() int {
 		return failure
 	}
 
+	if isPseudoTag(tg) {
+		c.errorf("(subcommand tag) Error: %q is reserved for elos", tg)
+		return failure
+	}
+
 	tag.Task(tsk, tg)
 
 	if err := c.DB.Save(tsk); err != nil {
@@ -639,13 +1951,72 @@ func (c *TodoCommand) runTag() int {
 	return success
 }
 
+// runTagAll runs the 'tag' subcommand with the -all or -t flag, which
+// tags every task, or every task carrying an existing tag, at once.
+func (c *TodoCommand) runTagAll(args []string) int {
+	selectors := make([]func(*models.Task) bool, 0, 1)
+
+	var tg string
+	switch {
+	case args[0] == "-all" && len(args) == 2:
+		tg = args[1]
+	case args[0] == "-t" && len(args) == 3:
+		existing := args[1]
+		selectors = append(selectors, func(t *models.Task) bool {
+			return hasTag(t, existing)
+		})
+		tg = args[2]
+	default:
+		c.errorf("(subcommand tag) Error: expected 'tag -all <tag>' or 'tag -t <existing> <tag>'")
+		return failure
+	}
+
+	if tg == "" {
+		c.errorf("(subcommand tag) Error: the tag can't be empty")
+		return failure
+	}
+
+	if isPseudoTag(tg) {
+		c.errorf("(subcommand tag) Error: %q is reserved for elos", tg)
+		return failure
+	}
+
+	c.UI.Output(fmt.Sprintf("Tagging these tasks with '%s':", tg))
+	c.printTaskList(selectors...)
+
+	n := 0
+TaskLoop:
+	for _, t := range c.tasks {
+		for _, selector := range selectors {
+			if !selector(t) {
+				continue TaskLoop
+			}
+		}
+
+		if hasTag(t, tg) {
+			continue
+		}
+
+		tag.Task(t, tg)
+		if err := c.DB.Save(t); err != nil {
+			c.errorf("saving task: %s", err)
+			return failure
+		}
+		n++
+	}
+
+	c.UI.Output(fmt.Sprintf("Tagged %d tasks", n))
+
+	return success
+}
+
 // runRemoveTag runs the 'tag' subcommand with the -r flag,
 // which removes a tag from a task
 func (c *TodoCommand) runRemoveTag() int {
 	c.UI.Output("Select which task to remove a tag from")
 	tsk, index := c.promptSelectTask()
 	if index < 0 {
-		return failure
+		return selectStatus(index)
 	}
 
 	c.UI.Output("Which tag to remove?")
@@ -681,18 +2052,24 @@ func (c *TodoCommand) runToday() int {
 	}).Execute()
 
 	if err != nil {
-		c.errorf("querying tasks: %s", err)
+		c.errorf("(subcommand today) Error: querying tasks: %v", err)
+		return failure
 	}
 
 	t := new(models.Task)
 	i := 0
 	for iter.Next(t) {
-		if task.IsComplete(t) && dayEquivalent(t.CompletedAt.Time().Local(), time.Now()) {
+		if task.IsComplete(t) && dayEquivalent(t.CompletedAt.Time().In(c.location()), time.Now().In(c.location())) {
 			c.UI.Output(fmt.Sprintf("%d) %s", i, String(t)))
 			i++
 		}
 	}
 
+	if err := iter.Close(); err != nil {
+		c.errorf("(subcommand today) Error: querying tasks: %v", err)
+		return failure
+	}
+
 	if i == 0 {
 		c.UI.Output("You have completed no tasks today")
 	}
@@ -700,47 +2077,217 @@ func (c *TodoCommand) runToday() int {
 	return success
 }
 
+// runWeight runs the 'weight' subcommand, which, given no arguments,
+// lists the tag weights, and given a tag and a weight, sets the weight
+// of the tag, i.e., "weight URGENT 2.0".
+//
+// The salience of a task is multiplied by the weight of each of its tags
+// when suggesting a task, so a weight above 1 favors tasks carrying the
+// tag, and a weight below 1 disfavors them.
+func (c *TodoCommand) runWeight(args []string) int {
+	switch len(args) {
+	case 0:
+		weights := c.tagWeights()
+		if len(weights) == 0 {
+			c.UI.Output("You have not weighted any tags")
+			return success
+		}
+
+		names := make([]string, 0, len(weights))
+		for name := range weights {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			c.UI.Output(fmt.Sprintf("%s: %g", name, weights[name]))
+		}
+
+		return success
+	case 2:
+	default:
+		c.errorf("(subcommand weight) Error: expected a tag and a weight, i.e., 'weight URGENT 2.0'")
+		return failure
+	}
+
+	name := args[0]
+	weight, err := strconv.ParseFloat(args[1], 64)
+	if err != nil || weight <= 0 {
+		c.errorf("(subcommand weight) Error: %q is not a positive number", args[1])
+		return failure
+	}
+
+	if c.Config == nil {
+		c.errorf("(subcommand weight) Error: no configuration to keep the weight in")
+		return failure
+	}
+
+	if c.Config.TagWeights == nil {
+		c.Config.TagWeights = make(map[string]float64)
+	}
+	c.Config.TagWeights[name] = weight
+
+	if err := WriteConfigFile(c.Config); err != nil {
+		c.errorf("(subcommand weight) Error: failed to persist configuration change: %s", err)
+		return failure
+	}
+
+	c.UI.Output(fmt.Sprintf("Weighted '%s' by %g", name, weight))
+
+	return success
+}
+
 // printTaskList prints the list of tasks, with deadline and salience info
 // the list is numbered, and can be useful for tasks that involve the user
 // looking at / selecting a particular task (however use promptSelectTask
 // for the case of selecting a single task from the c.tasks)
 func (c *TodoCommand) printTaskList(selectors ...func(*models.Task) bool) {
-PrintLoop:
+	indices := make([]int, 0, len(c.tasks))
+SelectLoop:
 	for i, t := range c.tasks {
 		for i := range selectors {
 			if !selectors[i](t) {
-				continue PrintLoop
+				continue SelectLoop
 			}
 		}
 
-		// Tags
-		tagList := ""
-		for _, n := range t.Tags {
-			tagList += fmt.Sprintf(" [%s]", n)
+		indices = append(indices, i)
+	}
+
+	// an input error just stops the paging early
+	page(c.UI, len(indices), c.pageSize, func(j int) {
+		c.printTask(indices[j])
+	})
+}
+
+// printTask prints the task at the given index of c.tasks,
+// for use in a numbered list
+func (c *TodoCommand) printTask(i int) {
+	t := c.tasks[i]
+
+	// Tags
+	tagList := ""
+	for _, n := range userTags(t) {
+		tagList += " " + colorize(colorDim, fmt.Sprintf("[%s]", n))
+	}
+	if tagList != "" {
+		tagList += ": "
+	} else {
+		tagList = " " + tagList
+	}
+
+	// Deadline, in red if it has passed
+	deadline := ""
+	if !t.DeadlineAt.IsZero() {
+		deadline = fmt.Sprintf("(%s)", formatTime(t.DeadlineAt.Time(), c.location()))
+		if t.DeadlineAt.Time().Before(time.Now()) {
+			deadline = colorize(colorRed, deadline)
 		}
-		if tagList != "" {
-			tagList += ": "
-		} else {
-			tagList = " " + tagList
+	}
+
+	c.UI.Output(fmt.Sprintf("%d)%s%s %s\n\tSalience:%f; Time Spent:%s", i, tagList, t.Name, deadline, task.Salience(t), task.TimeSpent(t)))
+}
+
+// tagWeights returns the configured weights of tags, by name
+func (c *TodoCommand) tagWeights() map[string]float64 {
+	if c.Config == nil {
+		return nil
+	}
+
+	return c.Config.TagWeights
+}
+
+// weightedSalience is the salience of the task, multiplied
+// by the weight of each of its tags which has one
+func weightedSalience(t *models.Task, weights map[string]float64) float64 {
+	s := task.Salience(t)
+	for _, name := range t.Tags {
+		if w, ok := weights[name]; ok {
+			s *= w
 		}
+	}
+	return s
+}
 
-		// Deadline
-		deadline := ""
-		if !t.DeadlineAt.IsZero() {
-			deadline = fmt.Sprintf("(%s)", t.DeadlineAt.Time().Local().Format("Mon Jan 2 15:04"))
+// byWeightedSalience sorts tasks by their weighted
+// salience, the most salient first
+type byWeightedSalience struct {
+	tasks   []*models.Task
+	weights map[string]float64
+}
+
+func (b byWeightedSalience) Len() int {
+	return len(b.tasks)
+}
+
+func (b byWeightedSalience) Less(i, j int) bool {
+	return weightedSalience(b.tasks[i], b.weights) > weightedSalience(b.tasks[j], b.weights)
+}
+
+func (b byWeightedSalience) Swap(i, j int) {
+	b.tasks[i], b.tasks[j] = b.tasks[j], b.tasks[i]
+}
+
+// suggest suggests one of c.tasks, using the task graph's suggestion.
+// If any tags are weighted, the graph is built from the tasks ordered
+// by their weighted salience, rather than their salience.
+func (c *TodoCommand) suggest() *models.Task {
+	weights := c.tagWeights()
+	if len(weights) == 0 {
+		return task.NewGraph(c.tasks).Suggest()
+	}
+
+	tasks := make([]*models.Task, len(c.tasks))
+	copy(tasks, c.tasks)
+	sort.Stable(byWeightedSalience{tasks, weights})
+
+	return task.NewGraph(tasks).Suggest()
+}
+
+// hasTag indicates whether the task carries the tag with the given name
+func hasTag(t *models.Task, name string) bool {
+	for _, tg := range t.Tags {
+		if tg == name {
+			return true
+		}
+	}
+	return false
+}
+
+// anyInProgress indicates whether any of the user's tasks are in progress
+func (c *TodoCommand) anyInProgress() bool {
+	for _, t := range c.tasks {
+		if task.InProgress(t) {
+			return true
 		}
+	}
+	return false
+}
+
+// cancelledIndex is the index promptSelectTask returns
+// when the user cancels the selection
+const cancelledIndex = -2
 
-		c.UI.Output(fmt.Sprintf("%d)%s%s %s\n\tSalience:%f; Time Spent:%s", i, tagList, t.Name, deadline, task.Salience(t), task.TimeSpent(t)))
+// selectStatus is the exit status of a subcommand whose task selection
+// failed with the given negative index. Cancelling is a clean abort, so
+// the status is success, any other failure is a failure.
+func selectStatus(index int) int {
+	if index == cancelledIndex {
+		return success
 	}
+
+	return failure
 }
 
 // promptSelectTask prompts the user to select one of their tasks. The
 // first return argument is the task the user selected, and the second is
 // the index of that task. If the index is negative, then there was either an
-// error retrieving a task selection from the user, or the user has no tasks,
-// in either case the value of the first return argument is undefined.
+// error retrieving a task selection from the user, the user cancelled, or the
+// user has no tasks, in any case the value of the first return argument is
+// undefined. If the user cancelled, the index is cancelledIndex.
 //
-// Use promptSelectTask for todo subcommands which operate on a task.
+// Use promptSelectTask for todo subcommands which operate on a task, and
+// selectStatus for their exit status when the index is negative.
 func (c *TodoCommand) promptSelectTask(selectors ...func(*models.Task) bool) (*models.Task, int) {
 	if len(c.tasks) == 0 {
 		c.UI.Warn("You do not have any tasks")
@@ -754,16 +2301,16 @@ func (c *TodoCommand) promptSelectTask(selectors ...func(*models.Task) bool) (*m
 		err            error
 	)
 
-	if indexOfCurrent, err = intInput(c.UI, "Which number?"); err != nil {
+	if indexOfCurrent, err = intRangeInput(c.UI, "Which number?", 0, len(c.tasks)-1); err != nil {
+		// cancelling is a clean abort, not an error
+		if err == ErrCancelled {
+			return nil, cancelledIndex
+		}
+
 		c.errorf("input error: %s", err)
 		return nil, -1
 	}
 
-	if indexOfCurrent < 0 || indexOfCurrent > len(c.tasks)-1 {
-		c.UI.Warn(fmt.Sprintf("%d is not a valid index. Need a # in (0,...,%d)", indexOfCurrent, len(c.tasks)-1))
-		return nil, -1 // to indicate the parent command to exit
-	}
-
 	return c.tasks[indexOfCurrent], indexOfCurrent
 }
 
@@ -777,6 +2324,7 @@ func (c *TodoCommand) promptSelectTask(selectors ...func(*models.Task) bool) (*m
 func (c *TodoCommand) promptNewTask() (task *models.Task, err error) {
 	var (
 		hasDeadline bool
+		hasEstimate bool
 		hasPrereqs  bool
 	)
 
@@ -792,11 +2340,24 @@ func (c *TodoCommand) promptNewTask() (task *models.Task, err error) {
 	if hasDeadline, err = yesNo(c.UI, "Does it have a deadline?"); err != nil {
 		return
 	} else if hasDeadline {
-		if task.DeadlineAt, err = timestamp(dateInput(c.UI, "Deadline:")); err != nil {
+		if task.DeadlineAt, err = timestamp(dateInputIn(c.UI, "Deadline:", c.location())); err != nil {
 			return
 		}
 	}
 
+	if hasEstimate, err = yesNo(c.UI, "Do you know how long it will take?"); err != nil {
+		return
+	} else if hasEstimate {
+		var est time.Duration
+		if est, err = durationInput(c.UI, "Estimate:"); err != nil {
+			return
+		}
+
+		if est > 0 {
+			setEstimate(task, est)
+		}
+	}
+
 	if hasPrereqs, err = yesNo(c.UI, "Does it have any prerequisites?"); err != nil {
 		return
 	} else if hasPrereqs {
@@ -865,6 +2426,43 @@ func (c *TodoCommand) promptNewTask() (task *models.Task, err error) {
 	return
 }
 
+// dependsOn reports whether the task with id from (transitively)
+// depends on the task with id to, by searching the prerequisites.
+//
+// Use it before adding a prerequisite to an existing task, as
+// task.NewGraph(...).Suggest() can't handle a cycle of prerequisites.
+// Prerequisites which have been deleted are ignored.
+func (c *TodoCommand) dependsOn(from, to string) (bool, error) {
+	visited := make(map[string]bool)
+	stack := []string{from}
+
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if id == to {
+			return true, nil
+		}
+
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+
+		t := &models.Task{Id: id}
+		switch err := c.DB.PopulateByID(t); err {
+		case nil:
+			stack = append(stack, t.PrerequisiteIds...)
+		case data.ErrNotFound:
+			continue
+		default:
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
 func (c *TodoCommand) printTagList(tags []string) {
 	for i, t := range tags {
 		c.UI.Output(fmt.Sprintf("%d) %s", i, t))
@@ -878,7 +2476,7 @@ func (c *TodoCommand) promptSelectTag() string {
 
 func (c *TodoCommand) promptSelectTagFromTask(t *models.Task) string {
 	var err error
-	tags := t.Tags
+	tags := userTags(t)
 
 	if len(tags) == 0 {
 		c.UI.Warn("That task has no tags")
@@ -891,13 +2489,10 @@ func (c *TodoCommand) promptSelectTagFromTask(t *models.Task) string {
 
 	var indexOfCurrent int
 
-	if indexOfCurrent, err = intInput(c.UI, "Which number?"); err != nil {
-		c.errorf("input error: %s", err)
-		return ""
-	}
-
-	if indexOfCurrent < 0 || indexOfCurrent > len(c.tasks)-1 {
-		c.UI.Warn(fmt.Sprintf("%d is not a valid index. Need a # in (0,...,%d)", indexOfCurrent, len(tags)-1))
+	if indexOfCurrent, err = intRangeInput(c.UI, "Which number?", 0, len(tags)-1); err != nil {
+		if err != ErrCancelled {
+			c.errorf("input error: %s", err)
+		}
 		return ""
 	}
 
@@ -907,7 +2502,7 @@ func (c *TodoCommand) promptSelectTagFromTask(t *models.Task) string {
 func String(t *models.Task) string {
 	// Tags
 	tagList := ""
-	for _, n := range t.Tags {
+	for _, n := range userTags(t) {
 		tagList += fmt.Sprintf(" [%s]", n)
 	}
 	if tagList != "" {
@@ -2,6 +2,11 @@ package command
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -61,6 +66,40 @@ func newMockTodoCommand(t *testing.T) (*cli.MockUi, data.DB, *models.User, *Todo
 	}
 }
 
+// closeErrDB is a data.DB whose query iterators
+// fail to close, with the error closeErr
+type closeErrDB struct {
+	data.DB
+}
+
+var closeErr = errors.New("connection reset")
+
+func (db closeErrDB) Query(k data.Kind) data.Query {
+	return closeErrQuery{db.DB.Query(k)}
+}
+
+type closeErrQuery struct {
+	data.Query
+}
+
+func (q closeErrQuery) Select(a data.AttrMap) data.Query {
+	return closeErrQuery{q.Query.Select(a)}
+}
+
+func (q closeErrQuery) Execute() (data.Iterator, error) {
+	iter, err := q.Query.Execute()
+	return closeErrIterator{iter}, err
+}
+
+type closeErrIterator struct {
+	data.Iterator
+}
+
+func (i closeErrIterator) Close() error {
+	i.Iterator.Close()
+	return closeErr
+}
+
 // --- }}}
 
 // --- Tests {{{
@@ -78,6 +117,36 @@ func TestTodoBasic(t *testing.T) {
 	}
 }
 
+// TestTodoCloseError tests that an error closing the
+// query of the tasks is reported along with its cause
+func TestTodoCloseError(t *testing.T) {
+	ui, db, _, c := newMockTodoCommand(t)
+	c.DB = closeErrDB{db}
+
+	if code := c.Run([]string{"list"}); code != failure {
+		t.Fatalf("Expected failure exit code, got %d", code)
+	}
+
+	if errput := ui.ErrorWriter.String(); !strings.Contains(errput, closeErr.Error()) {
+		t.Fatalf("Expected the error to contain the cause, got: %s", errput)
+	}
+}
+
+// TestTodoTodayCloseError tests that `today`, which queries
+// the tasks itself, reports an error closing the query
+func TestTodoTodayCloseError(t *testing.T) {
+	ui, db, _, c := newMockTodoCommand(t)
+	c.DB = closeErrDB{db}
+
+	if code := c.Run([]string{"today"}); code != failure {
+		t.Fatalf("Expected failure exit code, got %d", code)
+	}
+
+	if errput := ui.ErrorWriter.String(); !strings.Contains(errput, closeErr.Error()) {
+		t.Fatalf("Expected the error to contain the cause, got: %s", errput)
+	}
+}
+
 func TestTodoInadequateInitialization(t *testing.T) {
 	// mock cli.Ui
 	ui := new(cli.MockUi)
@@ -126,20 +195,24 @@ func TestTodoInadequateInitialization(t *testing.T) {
 
 // --- Integration {{{
 
-// --- `elos todo complete` {{{
+// --- `elos todo abandon` {{{
 
-// TestTodoComplete tests the `complete` subcommand
-func TestTodoComplete(t *testing.T) {
+// TestTodoAbandon tests the `abandon` subcommand, on a started task
+func TestTodoAbandon(t *testing.T) {
 	ui, db, user, c := newMockTodoCommand(t)
 
-	// setup that there is one task
 	tsk := newTestTask(t, db, user)
+	task.Start(tsk)
+	if err := db.Save(tsk); err != nil {
+		t.Fatal(err)
+	}
 
 	// load the input
 	ui.InputReader = bytes.NewBuffer([]byte("0\n"))
 
-	// run the effect of `elos todo complete`
-	code := c.Run([]string{"complete"})
+	t.Log("running: `elos todo abandon`")
+	code := c.Run([]string{"abandon"})
+	t.Log("command 'abandon' terminated")
 
 	errput := ui.ErrorWriter.String()
 	output := ui.OutputWriter.String()
@@ -156,47 +229,60 @@ func TestTodoComplete(t *testing.T) {
 		t.Fatalf("Expected successful exit code along with empty error output.")
 	}
 
-	// verify some of the output
-	if !strings.Contains(output, "0)") {
-		t.Fatalf("Output should have contained a 0) for listing tasks")
-	}
-
-	if !strings.Contains(output, "Which number?") {
-		t.Fatalf("Output should have asked for a task number")
+	if !strings.Contains(output, "Abandoned") {
+		t.Fatalf("Output should have said the task was abandoned")
 	}
 
-	t.Log("Checking that the task was completed")
-
 	if err := db.PopulateByID(tsk); err != nil {
 		t.Fatal(err)
 	}
 
-	t.Logf("Here's the task:\n%+v", tsk)
+	if task.InProgress(tsk) {
+		t.Fatalf("Expected the task to _not_ be in progress")
+	}
 
-	if !task.IsComplete(tsk) {
-		t.Fatalf("Expected the task to be complete")
+	if len(tsk.Stages) != 0 {
+		t.Fatalf("Expected no stages to be recorded, got %d", len(tsk.Stages))
 	}
 }
 
 // --- }}}
 
-// --- `elos todo current` {{{
+// --- `elos todo clean` {{{
 
-// TestTodoCurrent tests the `current` subcommand
-func TestTodoCurrent(t *testing.T) {
+// TestTodoClean tests the `clean` subcommand
+func TestTodoClean(t *testing.T) {
 	ui, db, user, c := newMockTodoCommand(t)
 
-	// setup that there is one task
-	tsk := newTestTask(t, db, user)
-	taskName := "task name"
-	tsk.Name = taskName
-	task.Start(tsk)
-	if err := db.Save(tsk); err != nil {
+	old := newTestTask(t, db, user)
+	old.Name = "old"
+	task.StopAndComplete(old)
+	old.CompletedAt = models.TimestampFrom(time.Now().AddDate(0, 0, -100))
+	if err := db.Save(old); err != nil {
 		t.Fatal(err)
 	}
 
-	// run the effect of `elos todo complete`
-	code := c.Run([]string{"current"})
+	recent := newTestTask(t, db, user)
+	recent.Name = "recent"
+	task.StopAndComplete(recent)
+	recent.CompletedAt = models.TimestampFrom(time.Now().AddDate(0, 0, -10))
+	if err := db.Save(recent); err != nil {
+		t.Fatal(err)
+	}
+
+	// another user's old task is never deleted
+	other := newTestTask(t, db, newTestUserX(t, db))
+	task.StopAndComplete(other)
+	other.CompletedAt = models.TimestampFrom(time.Now().AddDate(0, 0, -100))
+	if err := db.Save(other); err != nil {
+		t.Fatal(err)
+	}
+
+	ui.InputReader = bytes.NewBufferString("y\n")
+
+	t.Log("running: `elos todo clean -older 90d`")
+	code := c.Run([]string{"clean", "-older", "90d"})
+	t.Log("command 'clean' terminated")
 
 	errput := ui.ErrorWriter.String()
 	output := ui.OutputWriter.String()
@@ -213,28 +299,39 @@ func TestTodoCurrent(t *testing.T) {
 		t.Fatalf("Expected successful exit code along with empty error output.")
 	}
 
-	// verify some of the output
-	if !strings.Contains(output, taskName) {
-		t.Fatalf("Output should have contained the running task's name: '%s'", taskName)
+	if !strings.Contains(output, "Delete 1 tasks") {
+		t.Fatal("Expected to be asked to confirm deleting the one old task")
+	}
+
+	if err := db.PopulateByID(old); err != data.ErrNotFound {
+		t.Fatalf("Expected the old task to be deleted, got: %v", err)
+	}
+
+	if err := db.PopulateByID(recent); err != nil {
+		t.Fatalf("Expected the recent task to remain, got: %s", err)
+	}
+
+	if err := db.PopulateByID(other); err != nil {
+		t.Fatalf("Expected the other user's task to remain, got: %s", err)
 	}
 }
 
 // --- }}}
 
-// --- `elos todo delete` {{{
+// --- `elos todo complete` {{{
 
-// TestTodoDelete test the `delete` subcommand
-func TestTodoDelete(t *testing.T) {
+// TestTodoComplete tests the `complete` subcommand
+func TestTodoComplete(t *testing.T) {
 	ui, db, user, c := newMockTodoCommand(t)
 
-	// load a task into the db
-	task := newTestTask(t, db, user)
+	// setup that there is one task
+	tsk := newTestTask(t, db, user)
 
 	// load the input
 	ui.InputReader = bytes.NewBuffer([]byte("0\n"))
 
-	// run `elos todo delete`
-	code := c.Run([]string{"delete"})
+	// run the effect of `elos todo complete`
+	code := c.Run([]string{"complete"})
 
 	errput := ui.ErrorWriter.String()
 	output := ui.OutputWriter.String()
@@ -260,44 +357,34 @@ func TestTodoDelete(t *testing.T) {
 		t.Fatalf("Output should have asked for a task number")
 	}
 
-	t.Log("Trying to load the task from the database")
-	err := db.PopulateByID(task)
-	if err != data.ErrNotFound {
-		t.Fatal("Expected the task to be not found, as it should have been deleted")
+	t.Log("Checking that the task was completed")
+
+	if err := db.PopulateByID(tsk); err != nil {
+		t.Fatal(err)
 	}
-}
 
-// --- }}}
+	t.Logf("Here's the task:\n%+v", tsk)
 
-// --- `elos todo edit` {{{
+	if !task.IsComplete(tsk) {
+		t.Fatalf("Expected the task to be complete")
+	}
+}
 
-// TestTodoEdit tests the `edit` subcommand
-func TestTodoEdit(t *testing.T) {
+// TestTodoCompleteCancel tests that cancelling the selection
+// of the task to complete is a clean abort
+func TestTodoCompleteCancel(t *testing.T) {
 	ui, db, user, c := newMockTodoCommand(t)
 
-	// load a task into the db
-	task := newTestTask(t, db, user)
-	task.Name = "Take out the trash"
-	if err := db.Save(task); err != nil {
-		t.Fatal(err)
-	}
+	tsk := newTestTask(t, db, user)
 
-	// load input
-	input := strings.Join([]string{
-		"0",
-		"name",
-		"newname",
-	}, "\n")
-	ui.InputReader = bytes.NewBufferString(input)
+	ui.InputReader = bytes.NewBufferString("q\n")
 
-	t.Log("running: `elos todo edit`")
-	code := c.Run([]string{"edit"})
-	t.Log("command 'edit' terminated")
+	t.Log("running: `elos todo complete`")
+	code := c.Run([]string{"complete"})
+	t.Log("command 'complete' terminated")
 
 	errput := ui.ErrorWriter.String()
-	output := ui.OutputWriter.String()
 	t.Logf("Error output:\n %s", errput)
-	t.Logf("Output:\n %s", output)
 
 	// verify there were no errors
 	if errput != "" {
@@ -306,65 +393,37 @@ func TestTodoEdit(t *testing.T) {
 
 	// verify success
 	if code != success {
-		t.Fatalf("Expected successful exit code along with empty error output.")
-	}
-
-	// verify some of the output
-	if !strings.Contains(output, "0)") {
-		t.Fatalf("Output should have contained a 0) for listing tasks")
+		t.Fatalf("Expected successful exit code on cancel, got %d", code)
 	}
 
-	if !strings.Contains(output, "Which number?") {
-		t.Fatalf("Output should have asked for a task number")
-	}
-
-	t.Log("Checking that the task's name was changed")
-
-	if err := db.PopulateByID(task); err != nil {
+	if err := db.PopulateByID(tsk); err != nil {
 		t.Fatal(err)
 	}
 
-	t.Logf("Here's the task:\n%+v", task)
-
-	if task.Name != "newname" {
-		t.Fatalf("Expected the task's name to have changed to 'newname'")
+	if task.IsComplete(tsk) {
+		t.Fatal("Expected the task not to be completed")
 	}
 }
 
-// --- }}}
-
-// --- `elos todo fix` {{{
-
-// TestTodoFix tests the `fix` subcommand
-func TestTodoFix(t *testing.T) {
+// TestTodoStopCancel tests that cancelling the selection
+// of the task to stop is a clean abort
+func TestTodoStopCancel(t *testing.T) {
 	ui, db, user, c := newMockTodoCommand(t)
 
-	// load a task into the db
-	task := newTestTask(t, db, user)
-	task.Name = "Take out the trash"
-	task.DeadlineAt = models.TimestampFrom(time.Now().Add(-36 * time.Hour))
-	if err := db.Save(task); err != nil {
+	tsk := newTestTask(t, db, user)
+	task.Start(tsk)
+	if err := db.Save(tsk); err != nil {
 		t.Fatal(err)
 	}
 
-	// load input
-	input := strings.Join([]string{
-		"2020", // year
-		"1",    // month
-		"1",    // day
-		"12",   // hour
-		"0",    // minute
-	}, "\n")
-	ui.InputReader = bytes.NewBufferString(input)
+	ui.InputReader = bytes.NewBufferString("q\n")
 
-	t.Log("running: `elos todo fix`")
-	code := c.Run([]string{"fix"})
-	t.Log("command 'fix' terminated")
+	t.Log("running: `elos todo stop`")
+	code := c.Run([]string{"stop"})
+	t.Log("command 'stop' terminated")
 
 	errput := ui.ErrorWriter.String()
-	output := ui.OutputWriter.String()
 	t.Logf("Error output:\n %s", errput)
-	t.Logf("Output:\n %s", output)
 
 	// verify there were no errors
 	if errput != "" {
@@ -373,55 +432,58 @@ func TestTodoFix(t *testing.T) {
 
 	// verify success
 	if code != success {
-		t.Fatalf("Expected successful exit code along with empty error output.")
+		t.Fatalf("Expected successful exit code on cancel, got %d", code)
 	}
 
-	// verify some of the output
-	if !strings.Contains(output, task.Name) {
-		t.Fatalf("Output should have contained a the out of date task's name")
+	if err := db.PopulateByID(tsk); err != nil {
+		t.Fatal(err)
 	}
 
-	if !strings.Contains(output, "New Deadline") {
-		t.Fatalf("Output should have asked for a new deadline")
+	if !task.InProgress(tsk) {
+		t.Fatal("Expected the task to still be in progress")
 	}
+}
 
-	t.Log("Checking that the task's deadline was changed")
-
-	if err := db.PopulateByID(task); err != nil {
-		t.Fatal(err)
-	}
+// TestTodoCompleteEstimate tests that completing a task created
+// with an estimate compares the estimate to the time spent
+func TestTodoCompleteEstimate(t *testing.T) {
+	ui, db, _, c := newMockTodoCommand(t)
 
-	t.Logf("Here's the task:\n%+v", task)
+	ui.InputReader = bytes.NewBufferString(strings.Join([]string{
+		"estimated", // name
+		"n",         // deadline?
+		"y",         // estimate?
+		"2h",        // estimate
+		"n",         // prereqs?
+	}, "\n"))
 
-	if !task.DeadlineAt.Time().After(time.Now()) {
-		t.Fatalf("Expected the task's deadline to be after now")
+	t.Log("running: `elos todo new`")
+	if code := c.Run([]string{"new"}); code != success {
+		t.Fatalf("Expected success creating the task, got: %s", ui.ErrorWriter.String())
 	}
-}
-
-// --- }}}
+	t.Log("command 'new' terminated")
 
-// --- `elos todo goal` {{{
+	tsk := new(models.Task)
+	if err := db.PopulateByField("name", "estimated", tsk); err != nil {
+		t.Fatal(err)
+	}
 
-// TestTodoGoal tests the `goal` subcommand
-func TestTodoGoal(t *testing.T) {
-	ui, db, user, c := newMockTodoCommand(t)
+	if est, ok := estimate(tsk); !ok || est != 2*time.Hour {
+		t.Fatalf("Expected an estimate of 2h, got %s (%t)", est, ok)
+	}
 
-	// load a task into the db
-	task := newTestTask(t, db, user)
-	task.Name = "Take out the trash"
-	if err := db.Save(task); err != nil {
+	// the task was started three hours ago
+	tsk.Stages = []*models.Timestamp{models.TimestampFrom(time.Now().Add(-3 * time.Hour))}
+	if err := db.Save(tsk); err != nil {
 		t.Fatal(err)
 	}
 
-	// load input
-	input := strings.Join([]string{
-		"0",
-	}, "\n")
-	ui.InputReader = bytes.NewBufferString(input)
+	ui.OutputWriter.Reset()
+	ui.InputReader = bytes.NewBufferString("0\n")
 
-	t.Log("running: `elos todo goal`")
-	code := c.Run([]string{"goal"})
-	t.Log("command 'goal' terminated")
+	t.Log("running: `elos todo complete`")
+	code := c.Run([]string{"complete"})
+	t.Log("command 'complete' terminated")
 
 	errput := ui.ErrorWriter.String()
 	output := ui.OutputWriter.String()
@@ -438,74 +500,108 @@ func TestTodoGoal(t *testing.T) {
 		t.Fatalf("Expected successful exit code along with empty error output.")
 	}
 
-	// verify some of the output
-	if !strings.Contains(output, "0)") {
-		t.Fatalf("Output should have contained a 0) for listing tasks")
+	if !strings.Contains(output, "Estimated 2h0m0s, actually spent 3h") {
+		t.Fatal("Expected the estimate to be compared to the time spent")
 	}
 
-	if !strings.Contains(output, "Which number?") {
-		t.Fatalf("Output should have asked for a task number")
+	if !strings.Contains(output, "(50% over)") {
+		t.Fatal("Expected the task to be 50% over its estimate")
 	}
+}
 
-	t.Log("Checking that the task is now a member of goals")
+// TestTodoEstimateTagHidden tests that the tag holding the
+// estimate of a task isn't displayed as one of its tags
+func TestTodoEstimateTagHidden(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
 
-	// reload task:
-	if err := db.PopulateByID(task); err != nil {
+	tsk := newTestTask(t, db, user)
+	tsk.Name = "estimated"
+	tag.Task(tsk, "work")
+	setEstimate(tsk, 2*time.Hour)
+	if err := db.Save(tsk); err != nil {
 		t.Fatal(err)
 	}
 
-	t.Logf("Task:\n%+v", task)
-
-	tg := "GOAL"
-
-	t.Logf("GOALS tag:\n%+v", tg)
-
-	tasks, err := tag.TasksFor(db, c.UserID, tg)
-	if err != nil {
-		t.Fatal(err)
+	if code := c.Run([]string{"list"}); code != success {
+		t.Fatalf("Expected successful exit code, got %d", code)
 	}
 
-	if len(tasks) != 1 {
-		t.Fatalf("Expected goals tag to contain just one task, contained: %d", len(tasks))
+	output := ui.OutputWriter.String()
+	t.Logf("Output:\n %s", output)
+
+	if !strings.Contains(output, "[work]") {
+		t.Fatal("Expected the task's tag to be listed")
 	}
 
-	if tasks[0].Id != task.Id {
-		t.Fatal("Expected task to now be a part of goals")
+	if strings.Contains(output, estimateTagPrefix) {
+		t.Fatal("Expected the estimate not to be listed as a tag")
 	}
 }
 
 // --- }}}
 
-// --- `elos todo goals` {{{
+// --- `elos todo current` {{{
 
-// TestTodoGoals tests the `goals` subcommand
-func TestTodoGoals(t *testing.T) {
+// TestTodoCurrent tests the `current` subcommand
+func TestTodoCurrent(t *testing.T) {
 	ui, db, user, c := newMockTodoCommand(t)
 
-	// load a task into the db
-	task := newTestTask(t, db, user)
-	task.Name = "Take out the trash"
-	if err := db.Save(task); err != nil {
+	// setup that there is one task
+	tsk := newTestTask(t, db, user)
+	taskName := "task name"
+	tsk.Name = taskName
+	task.Start(tsk)
+	if err := db.Save(tsk); err != nil {
 		t.Fatal(err)
 	}
 
-	tg := "GOAL"
+	// run the effect of `elos todo complete`
+	code := c.Run([]string{"current"})
 
-	for _, t := range task.Tags {
-		if t == tg {
-			goto dontadd
-		}
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
 	}
-	task.Tags = append(task.Tags, tg)
-dontadd:
 
-	if err := db.Save(task); err != nil {
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// verify some of the output
+	if !strings.Contains(output, taskName) {
+		t.Fatalf("Output should have contained the running task's name: '%s'", taskName)
+	}
+}
+
+// TestTodoCurrentAll tests the `current` subcommand with --next,
+// which also lists tasks that haven't been started
+func TestTodoCurrentAll(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	started := newTestTask(t, db, user)
+	started.Name = "started task"
+	task.Start(started)
+	if err := db.Save(started); err != nil {
 		t.Fatal(err)
 	}
 
-	t.Log("running: `elos todo goals`")
-	code := c.Run([]string{"goals"})
-	t.Log("command 'goals' terminated")
+	for _, name := range []string{"first upcoming", "second upcoming"} {
+		tsk := newTestTask(t, db, user)
+		tsk.Name = name
+		if err := db.Save(tsk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Log("running: `elos todo current --next 1`")
+	code := c.Run([]string{"current", "--next", "1"})
+	t.Log("command 'current' terminated")
 
 	errput := ui.ErrorWriter.String()
 	output := ui.OutputWriter.String()
@@ -522,39 +618,93 @@ dontadd:
 		t.Fatalf("Expected successful exit code along with empty error output.")
 	}
 
-	// verify some of the output
-	if !strings.Contains(output, "0)") {
-		t.Fatalf("Output should have contained a 0) for listing tasks")
+	if !strings.Contains(output, "started task") {
+		t.Fatalf("Output should have contained the started task")
 	}
 
-	if !strings.Contains(output, task.Name) {
-		t.Fatalf("Output should have contained task name")
+	if !strings.Contains(output, "Up next:") {
+		t.Fatalf("Output should have listed the upcoming tasks")
 	}
 
+	if n := strings.Count(output, "upcoming"); n != 1 {
+		t.Fatalf("Expected exactly one upcoming task, got %d", n)
+	}
 }
 
 // --- }}}
 
-// --- `elos todo list` {{{
+// --- `elos todo deadline` {{{
 
-// TestTodoList test the `list` subcommand
-func TestTodoList(t *testing.T) {
+// TestTodoDeadline tests the `deadline` subcommand, with arguments
+func TestTodoDeadline(t *testing.T) {
 	ui, db, user, c := newMockTodoCommand(t)
 
-	task1 := newTestTask(t, db, user)
-	task2 := newTestTask(t, db, user)
-	task1.Name = "task1"
-	if err := db.Save(task1); err != nil {
+	tsk := newTestTask(t, db, user)
+	tsk.Name = "Take out the trash"
+	if err := db.Save(tsk); err != nil {
 		t.Fatal(err)
 	}
-	task2.Name = "task2"
-	if err := db.Save(task2); err != nil {
+
+	t.Log("running: `elos todo deadline 0 tomorrow`")
+	code := c.Run([]string{"deadline", "0", "tomorrow"})
+	t.Log("command 'deadline' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	if err := db.PopulateByID(tsk); err != nil {
 		t.Fatal(err)
 	}
 
-	t.Log("running: `elos todo list`")
-	code := c.Run([]string{"list"})
-	t.Log("command 'list' terminated")
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	if !dayEquivalent(tsk.DeadlineAt.Time().Local(), tomorrow) {
+		t.Fatalf("Expected the deadline to be tomorrow, got %s", tsk.DeadlineAt.Time())
+	}
+}
+
+// TestTodoDeadlineBadIndex tests the `deadline` subcommand,
+// with an index out of range
+func TestTodoDeadlineBadIndex(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+	newTestTask(t, db, user)
+
+	if code := c.Run([]string{"deadline", "3", "tomorrow"}); code != failure {
+		t.Fatalf("Expected failure exit code, got %d", code)
+	}
+
+	if !strings.Contains(ui.ErrorWriter.String(), "not a valid index") {
+		t.Fatalf("Error output should have said the index is invalid")
+	}
+}
+
+// --- }}}
+
+// --- `elos todo delete` {{{
+
+// TestTodoDelete test the `delete` subcommand
+func TestTodoDelete(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	// load a task into the db
+	task := newTestTask(t, db, user)
+
+	// load the input
+	ui.InputReader = bytes.NewBuffer([]byte("0\n"))
+
+	// run `elos todo delete`
+	code := c.Run([]string{"delete"})
 
 	errput := ui.ErrorWriter.String()
 	output := ui.OutputWriter.String()
@@ -576,47 +726,43 @@ func TestTodoList(t *testing.T) {
 		t.Fatalf("Output should have contained a 0) for listing tasks")
 	}
 
-	if !strings.Contains(output, "1)") {
-		t.Fatalf("Output should have contained a 1) for listing tasks")
-	}
-
-	if !strings.Contains(output, "task1") {
-		t.Fatalf("Output should have contained 'task1' the name of the first task")
+	if !strings.Contains(output, "Which number?") {
+		t.Fatalf("Output should have asked for a task number")
 	}
 
-	if !strings.Contains(output, "task2") {
-		t.Fatalf("Output should have contained 'task2' the name of the second task")
+	t.Log("Trying to load the task from the database")
+	err := db.PopulateByID(task)
+	if err != data.ErrNotFound {
+		t.Fatal("Expected the task to be not found, as it should have been deleted")
 	}
 }
 
 // --- }}}
 
-// --- `elos todo list -t` {{{
+// --- `elos todo edit` {{{
 
-// TestTodoListTag test the `list -t` subcommand
-func TestTodoListTag(t *testing.T) {
-	// TODO(nclandolfi): fix flaky test
-	t.Skip()
+// TestTodoEdit tests the `edit` subcommand
+func TestTodoEdit(t *testing.T) {
 	ui, db, user, c := newMockTodoCommand(t)
 
-	task1 := newTestTask(t, db, user)
-	task2 := newTestTask(t, db, user)
-	task1.Name = "task1"
-	if err := db.Save(task1); err != nil {
-		t.Fatal(err)
-	}
-	task2.Name = "task2"
-	if err := db.Save(task2); err != nil {
+	// load a task into the db
+	task := newTestTask(t, db, user)
+	task.Name = "Take out the trash"
+	if err := db.Save(task); err != nil {
 		t.Fatal(err)
 	}
 
-	tagName := "TAGNAME"
-	tag.Task(task1, tagName)
-	ui.InputReader = bytes.NewBufferString("TAGNAME\n") // select first and only tag
+	// load input
+	input := strings.Join([]string{
+		"0",
+		"name",
+		"newname",
+	}, "\n")
+	ui.InputReader = bytes.NewBufferString(input)
 
-	t.Log("running: `elos todo list -t`")
-	code := c.Run([]string{"list", "-t"})
-	t.Log("command 'list -t' terminated")
+	t.Log("running: `elos todo edit`")
+	code := c.Run([]string{"edit"})
+	t.Log("command 'edit' terminated")
 
 	errput := ui.ErrorWriter.String()
 	output := ui.OutputWriter.String()
@@ -638,74 +784,47 @@ func TestTodoListTag(t *testing.T) {
 		t.Fatalf("Output should have contained a 0) for listing tasks")
 	}
 
-	if !strings.Contains(output, "task1") {
-		t.Fatalf("Output should have contained 'task1' the name of the first task")
+	if !strings.Contains(output, "Which number?") {
+		t.Fatalf("Output should have asked for a task number")
 	}
-}
 
-// --- }}}
+	t.Log("Checking that the task's name was changed")
 
-// --- `elos todo new` {{{
+	if err := db.PopulateByID(task); err != nil {
+		t.Fatal(err)
+	}
 
-// TestTodoNew tests the `new` subcommand
-func TestTodoNew(t *testing.T) {
-	ui, db, user, c := newMockTodoCommand(t)
+	t.Logf("Here's the task:\n%+v", task)
 
-	// set up tasks
-	task1 := newTestTask(t, db, user)
-	task1.Name = "task1"
-	task2 := newTestTask(t, db, user)
-	task2.Name = "task2"
-	if err := db.Save(task1); err != nil {
-		t.Fatal(err)
+	if task.Name != "newname" {
+		t.Fatalf("Expected the task's name to have changed to 'newname'")
 	}
-	if err := db.Save(task2); err != nil {
+}
+
+// TestTodoEditFutureCompletedAt tests that the `edit` subcommand
+// rejects a completed_at in the future
+func TestTodoEditFutureCompletedAt(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	tsk := newTestTask(t, db, user)
+	tsk.CreatedAt = models.TimestampFrom(time.Date(2015, time.January, 1, 0, 0, 0, 0, time.Local))
+	if err := db.Save(tsk); err != nil {
 		t.Fatal(err)
 	}
 
-	// Here we create 3 tasks, the first has a deadline and
-	// is called "top". "top" has "task1" and "task2" as
-	// prereqs. Then we create another prereq: 'sub'. 'sub'
-	// has no deadline, but has one prereq, another task
-	// we create, called 'bottom'
+	// load input, a date in the future and then one in the past
 	input := strings.Join([]string{
-		"top",    // Name
-		"y",      // Does it have a deadline
-		"2020",   // year
-		"1",      // month
-		"1",      // date
-		"12",     // hour
-		"0",      // minute
-		"y",      // prereqs?
-		"y",      // current tasks?
-		"0",      // index of prereq
-		"y",      // any more current prereqs?
-		"0",      // shouldn't error, even though already added
-		"y",      // any more current prereqs?
-		"1",      // now add `task2`
-		"n",      // any more current prereqs?
-		"y",      // any dependencies that are new?
-		"sub",    // name
-		"n",      // deadline
-		"y",      // prereqs?
-		"n",      // current?
-		"y",      // new?
-		"bottom", // name
-		"n",      //deadline?
-		"n",      //prereqs? => Task Created
-		"n",      // any more new prereqs? => TaskCreated
-		"n",      // any more new prereqs? => TaskCreated
+		"0",
+		"completed_at",
+		"9999", "1", "1", "0", "0",
+		"2016", "1", "1", "0", "0",
 	}, "\n")
-
-	// load input
 	ui.InputReader = bytes.NewBufferString(input)
 
-	// run command
-	t.Log("running: `elos todo new`")
-	code := c.Run([]string{"new"})
-	t.Log("command 'new' terminated")
+	t.Log("running: `elos todo edit`")
+	code := c.Run([]string{"edit"})
+	t.Log("command 'edit' terminated")
 
-	// basic checks
 	errput := ui.ErrorWriter.String()
 	output := ui.OutputWriter.String()
 	t.Logf("Error output:\n %s", errput)
@@ -721,78 +840,1473 @@ func TestTodoNew(t *testing.T) {
 		t.Fatalf("Expected successful exit code along with empty error output.")
 	}
 
-	// a small sample of keywords in output
-	if !strings.Contains(output, "Task created") {
-		t.Fatalf("Output should contain 'Task Created'")
+	// verify the future date was rejected
+	if !strings.Contains(output, "completed_at can't be in the future") {
+		t.Fatalf("Output should have rejected the future completed_at")
 	}
-	if !strings.Contains(output, "deadline") {
-		t.Fatalf("Output should have contained 'deadline'")
+
+	if err := db.PopulateByID(tsk); err != nil {
+		t.Fatal(err)
 	}
 
-	// now to verify that the tasks were created
-	// first top
-	top := new(models.Task)
-	if err := db.PopulateByField("name", "top", top); err != nil {
+	if got, want := tsk.CompletedAt.Time().Year(), 2016; got != want {
+		t.Fatalf("CompletedAt year: got %d, want %d", got, want)
+	}
+}
+
+// TestTodoEditPrerequisites tests removing a prerequisite with
+// the `edit` subcommand
+func TestTodoEditPrerequisites(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	// the prerequisites are complete, so only top is listed
+	done := newTestTask(t, db, user)
+	done.Name = "done"
+	task.StopAndComplete(done)
+	if err := db.Save(done); err != nil {
 		t.Fatal(err)
 	}
-	t.Logf("top: %+v", top)
 
-	if len(top.PrerequisiteIds) != 3 {
-		t.Fatal("Expected 'top' to have 3 prereqs")
+	also := newTestTask(t, db, user)
+	also.Name = "also done"
+	task.StopAndComplete(also)
+	if err := db.Save(also); err != nil {
+		t.Fatal(err)
+	}
+
+	top := newTestTask(t, db, user)
+	top.Name = "top"
+	top.PrerequisiteIds = []string{done.Id, also.Id}
+	if err := db.Save(top); err != nil {
+		t.Fatal(err)
+	}
+
+	// load input
+	input := strings.Join([]string{
+		"0",
+		"prerequisites",
+		"n", // remove, rather than add
+		"0",
+	}, "\n")
+	ui.InputReader = bytes.NewBufferString(input)
+
+	t.Log("running: `elos todo edit`")
+	code := c.Run([]string{"edit"})
+	t.Log("command 'edit' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// verify the prerequisites were listed by name
+	if !strings.Contains(output, "0) done") || !strings.Contains(output, "1) also done") {
+		t.Fatalf("Output should have listed the prerequisites by name")
+	}
+
+	if err := db.PopulateByID(top); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(top.PrerequisiteIds) != 1 || top.PrerequisiteIds[0] != also.Id {
+		t.Fatalf("Expected only the prerequisite %s to remain, got %v", also.Id, top.PrerequisiteIds)
+	}
+}
+
+// TestTodoEditPrerequisiteCycle tests that adding a prerequisite
+// with the `edit` subcommand refuses one which would form a cycle
+func TestTodoEditPrerequisiteCycle(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	// the deadlines order the tasks, b is more salient
+	a := newTestTask(t, db, user)
+	a.Name = "a"
+	a.DeadlineAt = models.TimestampFrom(time.Now().Add(7 * 24 * time.Hour))
+	if err := db.Save(a); err != nil {
+		t.Fatal(err)
+	}
+
+	b := newTestTask(t, db, user)
+	b.Name = "b"
+	b.DeadlineAt = models.TimestampFrom(time.Now().Add(time.Hour))
+	b.PrerequisiteIds = []string{a.Id}
+	if err := db.Save(b); err != nil {
+		t.Fatal(err)
+	}
+
+	// try to make b a prerequisite of a
+	input := strings.Join([]string{
+		"1", // a
+		"prerequisites",
+		"y", // add
+		"0", // b
+	}, "\n")
+	ui.InputReader = bytes.NewBufferString(input)
+
+	t.Log("running: `elos todo edit`")
+	code := c.Run([]string{"edit"})
+	t.Log("command 'edit' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	if !strings.Contains(errput, "'b' already depends on this task") {
+		t.Fatal("Expected a warning that b already depends on a")
+	}
+
+	if err := db.PopulateByID(a); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a.PrerequisiteIds) != 0 {
+		t.Fatalf("Expected a to have no prerequisites, got %d", len(a.PrerequisiteIds))
+	}
+}
+
+// --- }}}
+
+// --- `elos todo fix` {{{
+
+// TestTodoFix tests the `fix` subcommand
+func TestTodoFix(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	// load a task into the db
+	task := newTestTask(t, db, user)
+	task.Name = "Take out the trash"
+	task.DeadlineAt = models.TimestampFrom(time.Now().Add(-36 * time.Hour))
+	if err := db.Save(task); err != nil {
+		t.Fatal(err)
+	}
+
+	// load input
+	input := strings.Join([]string{
+		"2020", // year
+		"1",    // month
+		"1",    // day
+		"12",   // hour
+		"0",    // minute
+	}, "\n")
+	ui.InputReader = bytes.NewBufferString(input)
+
+	t.Log("running: `elos todo fix`")
+	code := c.Run([]string{"fix"})
+	t.Log("command 'fix' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// verify some of the output
+	if !strings.Contains(output, task.Name) {
+		t.Fatalf("Output should have contained a the out of date task's name")
+	}
+
+	if !strings.Contains(output, "New Deadline") {
+		t.Fatalf("Output should have asked for a new deadline")
+	}
+
+	t.Log("Checking that the task's deadline was changed")
+
+	if err := db.PopulateByID(task); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("Here's the task:\n%+v", task)
+
+	if !task.DeadlineAt.Time().After(time.Now()) {
+		t.Fatalf("Expected the task's deadline to be after now")
+	}
+}
+
+// --- }}}
+
+// --- `elos todo goal` {{{
+
+// TestTodoGoal tests the `goal` subcommand
+func TestTodoGoal(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	// load a task into the db
+	task := newTestTask(t, db, user)
+	task.Name = "Take out the trash"
+	if err := db.Save(task); err != nil {
+		t.Fatal(err)
+	}
+
+	// load input
+	input := strings.Join([]string{
+		"0",
+	}, "\n")
+	ui.InputReader = bytes.NewBufferString(input)
+
+	t.Log("running: `elos todo goal`")
+	code := c.Run([]string{"goal"})
+	t.Log("command 'goal' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// verify some of the output
+	if !strings.Contains(output, "0)") {
+		t.Fatalf("Output should have contained a 0) for listing tasks")
+	}
+
+	if !strings.Contains(output, "Which number?") {
+		t.Fatalf("Output should have asked for a task number")
+	}
+
+	t.Log("Checking that the task is now a member of goals")
+
+	// reload task:
+	if err := db.PopulateByID(task); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("Task:\n%+v", task)
+
+	tg := "GOAL"
+
+	t.Logf("GOALS tag:\n%+v", tg)
+
+	tasks, err := tag.TasksFor(db, c.UserID, tg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tasks) != 1 {
+		t.Fatalf("Expected goals tag to contain just one task, contained: %d", len(tasks))
+	}
+
+	if tasks[0].Id != task.Id {
+		t.Fatal("Expected task to now be a part of goals")
+	}
+}
+
+// TestTodoGoalTwice tests that running the `goal` subcommand
+// on a goal doesn't tag it with "GOAL" again
+func TestTodoGoalTwice(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	// load a task into the db
+	task := newTestTask(t, db, user)
+	task.Name = "Take out the trash"
+	if err := db.Save(task); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		ui.InputReader = bytes.NewBufferString("0\n")
+
+		t.Log("running: `elos todo goal`")
+		if code := c.Run([]string{"goal"}); code != success {
+			t.Fatalf("Expected successful exit code, got %d", code)
+		}
+		t.Log("command 'goal' terminated")
+	}
+
+	if errput := ui.ErrorWriter.String(); errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	if err := db.PopulateByID(task); err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	for _, tg := range task.Tags {
+		if tg == "GOAL" {
+			count++
+		}
+	}
+
+	if count != 1 {
+		t.Fatalf("Expected exactly one GOAL tag, got %d: %v", count, task.Tags)
+	}
+}
+
+// TestTodoGoalRemove tests the `goal` subcommand with the -r flag
+func TestTodoGoalRemove(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	// load a task into the db
+	task := newTestTask(t, db, user)
+	task.Name = "Take out the trash"
+	if err := db.Save(task); err != nil {
+		t.Fatal(err)
+	}
+
+	ui.InputReader = bytes.NewBufferString("0\n")
+
+	t.Log("running: `elos todo goal`")
+	if code := c.Run([]string{"goal"}); code != success {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+	t.Log("command 'goal' terminated")
+
+	// get a fresh ui
+	ui = new(cli.MockUi)
+	c.UI = ui
+	ui.InputReader = bytes.NewBufferString("0\n")
+
+	t.Log("running: `elos todo goal -r`")
+	code := c.Run([]string{"goal", "-r"})
+	t.Log("command 'goal -r' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	if !strings.Contains(output, "Removed") {
+		t.Fatalf("Output should have said the goal was removed")
+	}
+
+	tasks, err := tag.TasksFor(db, c.UserID, "GOAL")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tasks) != 0 {
+		t.Fatalf("Expected goals tag to contain no tasks, contained: %d", len(tasks))
+	}
+}
+
+// --- }}}
+
+// --- `elos todo goals` {{{
+
+// TestTodoGoals tests the `goals` subcommand
+func TestTodoGoals(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	// load a task into the db
+	task := newTestTask(t, db, user)
+	task.Name = "Take out the trash"
+	if err := db.Save(task); err != nil {
+		t.Fatal(err)
+	}
+
+	tg := "GOAL"
+
+	for _, t := range task.Tags {
+		if t == tg {
+			goto dontadd
+		}
+	}
+	task.Tags = append(task.Tags, tg)
+dontadd:
+
+	if err := db.Save(task); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("running: `elos todo goals`")
+	code := c.Run([]string{"goals"})
+	t.Log("command 'goals' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// verify some of the output
+	if !strings.Contains(output, "0)") {
+		t.Fatalf("Output should have contained a 0) for listing tasks")
+	}
+
+	if !strings.Contains(output, task.Name) {
+		t.Fatalf("Output should have contained task name")
+	}
+
+}
+
+// --- }}}
+
+// --- `elos todo import` {{{
+
+// TestTodoImport tests that the tasks output by `elos todo list --json`
+// are recreated by `elos todo import`
+func TestTodoImport(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	names := []string{"Take out the trash", "Write the report"}
+	for _, name := range names {
+		tsk := newTestTask(t, db, user)
+		tsk.Name = name
+		if err := db.Save(tsk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Log("running: `elos todo list --json`")
+	if code := c.Run([]string{"list", "--json"}); code != success {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	f, err := ioutil.TempFile("", "tasks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(ui.OutputWriter.String()); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	// wipe the tasks
+	for _, tsk := range c.tasks {
+		if err := db.Delete(tsk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ui = new(cli.MockUi)
+	c.UI = ui
+
+	t.Log("running: `elos todo import`")
+	code := c.Run([]string{"import", f.Name()})
+	t.Log("command 'import' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	if !strings.Contains(output, "Imported 2 tasks") {
+		t.Fatalf("Output should have said two tasks were imported")
+	}
+
+	// reload the tasks
+	if code := c.loadTasks(); code != success {
+		t.Fatalf("Expected successful loading of the tasks, got %d", code)
+	}
+
+	if len(c.tasks) != len(names) {
+		t.Fatalf("Expected %d tasks, got %d", len(names), len(c.tasks))
+	}
+
+	imported := make(map[string]bool)
+	for _, tsk := range c.tasks {
+		imported[tsk.Name] = true
+	}
+
+	for _, name := range names {
+		if !imported[name] {
+			t.Fatalf("Expected '%s' to have been imported", name)
+		}
+	}
+}
+
+// TestTodoImportManyFiles tests that importing from
+// more than one file is refused, rather than ignoring some
+func TestTodoImportManyFiles(t *testing.T) {
+	ui, _, _, c := newMockTodoCommand(t)
+
+	code := c.Run([]string{"import", "first.json", "second.json"})
+
+	if code != failure {
+		t.Fatalf("Expected failure exit code, got %d", code)
+	}
+
+	if errput := ui.ErrorWriter.String(); !strings.Contains(errput, "at most one file") {
+		t.Fatalf("Expected an error about the files, got: %s", errput)
+	}
+}
+
+// TestTodoImportPreserveIDsNoID tests that with -preserve-ids
+// a task without an id is skipped
+func TestTodoImportPreserveIDsNoID(t *testing.T) {
+	ui, _, _, c := newMockTodoCommand(t)
+
+	f, err := ioutil.TempFile("", "tasks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(`[{"name": "no id"}]`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	t.Log("running: `elos todo import -preserve-ids`")
+	code := c.Run([]string{"import", "-preserve-ids", f.Name()})
+	t.Log("command 'import' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	if !strings.Contains(errput, "Skipping 'no id', it has no id to preserve") {
+		t.Fatal("Expected the task without an id to be skipped")
+	}
+
+	if !strings.Contains(output, "Imported 0 tasks") {
+		t.Fatal("Expected no tasks to be imported")
+	}
+}
+
+// --- }}}
+
+// --- `elos todo list` {{{
+
+// TestTodoList test the `list` subcommand
+func TestTodoList(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	task1 := newTestTask(t, db, user)
+	task2 := newTestTask(t, db, user)
+	task1.Name = "task1"
+	if err := db.Save(task1); err != nil {
+		t.Fatal(err)
+	}
+	task2.Name = "task2"
+	if err := db.Save(task2); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("running: `elos todo list`")
+	code := c.Run([]string{"list"})
+	t.Log("command 'list' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// verify some of the output
+	if !strings.Contains(output, "0)") {
+		t.Fatalf("Output should have contained a 0) for listing tasks")
+	}
+
+	if !strings.Contains(output, "1)") {
+		t.Fatalf("Output should have contained a 1) for listing tasks")
+	}
+
+	if !strings.Contains(output, "task1") {
+		t.Fatalf("Output should have contained 'task1' the name of the first task")
+	}
+
+	if !strings.Contains(output, "task2") {
+		t.Fatalf("Output should have contained 'task2' the name of the second task")
+	}
+}
+
+// TestTodoListJSON tests the `list --json` subcommand
+func TestTodoListJSON(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	task1 := newTestTask(t, db, user)
+	task1.Name = "task1"
+	if err := db.Save(task1); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("running: `elos todo list --json`")
+	code := c.Run([]string{"list", "--json"})
+	t.Log("command `list --json` terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n%s", errput)
+	t.Logf("Output:\n%s", output)
+
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	var tasks []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(output), &tasks); err != nil {
+		t.Fatalf("Output should be valid JSON: %s", err)
+	}
+
+	if len(tasks) != 1 || tasks[0].Name != "task1" {
+		t.Fatalf("Expected the JSON to contain the task 'task1', got: %+v", tasks)
+	}
+}
+
+// TestTodoListCompleted tests the `list -completed` subcommand
+func TestTodoListCompleted(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	for _, name := range []string{"first", "second", "ancient", "open"} {
+		tsk := newTestTask(t, db, user)
+		tsk.Name = name
+
+		switch name {
+		case "first", "second":
+			task.StopAndComplete(tsk)
+		case "ancient":
+			task.StopAndComplete(tsk)
+			tsk.CompletedAt = models.TimestampFrom(time.Now().AddDate(0, 0, -10))
+		}
+
+		if err := db.Save(tsk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Log("running: `elos todo list -completed 3d`")
+	code := c.Run([]string{"list", "-completed", "3d"})
+	t.Log("command 'list -completed' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	for _, name := range []string{"first", "second"} {
+		if !strings.Contains(output, name) {
+			t.Fatalf("Expected the recently completed task '%s' to be listed", name)
+		}
+	}
+
+	if strings.Contains(output, "ancient") {
+		t.Fatal("Expected the task completed 10 days ago not to be listed")
+	}
+
+	if strings.Contains(output, "open") {
+		t.Fatal("Expected the incomplete task not to be listed")
+	}
+
+	if !strings.Contains(output, "Time Spent") {
+		t.Fatal("Expected the time spent on each task to be listed")
+	}
+}
+
+// --- }}}
+
+// --- `elos todo list -t` {{{
+
+// TestTodoListTag test the `list -t` subcommand
+func TestTodoListTag(t *testing.T) {
+	// TODO(nclandolfi): fix flaky test
+	t.Skip()
+	ui, db, user, c := newMockTodoCommand(t)
+
+	task1 := newTestTask(t, db, user)
+	task2 := newTestTask(t, db, user)
+	task1.Name = "task1"
+	if err := db.Save(task1); err != nil {
+		t.Fatal(err)
+	}
+	task2.Name = "task2"
+	if err := db.Save(task2); err != nil {
+		t.Fatal(err)
+	}
+
+	tagName := "TAGNAME"
+	tag.Task(task1, tagName)
+	ui.InputReader = bytes.NewBufferString("TAGNAME\n") // select first and only tag
+
+	t.Log("running: `elos todo list -t`")
+	code := c.Run([]string{"list", "-t"})
+	t.Log("command 'list -t' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// verify some of the output
+	if !strings.Contains(output, "0)") {
+		t.Fatalf("Output should have contained a 0) for listing tasks")
+	}
+
+	if !strings.Contains(output, "task1") {
+		t.Fatalf("Output should have contained 'task1' the name of the first task")
+	}
+}
+
+// --- }}}
+
+// --- `elos todo move` {{{
+
+// TestTodoMove tests the `move` subcommand, and `list -sort manual`
+func TestTodoMove(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	for i, name := range []string{"alpha", "bravo", "charlie"} {
+		tsk := newTestTask(t, db, user)
+		tsk.Name = name
+		setPriority(tsk, i)
+		if err := db.Save(tsk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if code := c.loadTasks(); code != success {
+		t.Fatalf("Expected successful loading of the tasks, got %d", code)
+	}
+
+	index := -1
+	for i, tsk := range c.tasks {
+		if tsk.Name == "charlie" {
+			index = i
+		}
+	}
+
+	t.Log("running: `elos todo move <charlie> 0`")
+	code := c.runMove([]string{strconv.Itoa(index), "0"})
+	t.Log("command 'move' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	ui.OutputWriter.Reset()
+
+	t.Log("running: `elos todo list -sort manual`")
+	if code := c.Run([]string{"list", "-sort", "manual"}); code != success {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	output = ui.OutputWriter.String()
+	t.Logf("Output:\n %s", output)
+
+	first, second, third := strings.Index(output, "charlie"), strings.Index(output, "alpha"), strings.Index(output, "bravo")
+	if first < 0 || second < 0 || third < 0 {
+		t.Fatal("Expected every task to be listed")
+	}
+
+	if !(first < second && second < third) {
+		t.Fatal("Expected the manual order to be charlie, alpha, bravo")
+	}
+}
+
+// TestTodoPriorityTagHidden tests that the tag holding the manual
+// priority of a task isn't displayed as one of its tags
+func TestTodoPriorityTagHidden(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	tsk := newTestTask(t, db, user)
+	tsk.Name = "alpha"
+	tag.Task(tsk, "work")
+	setPriority(tsk, 0)
+	if err := db.Save(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("running: `elos todo list`")
+	code := c.Run([]string{"list"})
+	t.Log("command 'list' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	if !strings.Contains(output, "[work]") {
+		t.Fatal("Expected the task's tag to be listed")
+	}
+
+	if strings.Contains(output, priorityTagPrefix) {
+		t.Fatal("Expected the priority not to be listed as a tag")
+	}
+
+	// nor can it be added by hand
+	ui.InputReader = bytes.NewBufferString("0\n" + priorityTagPrefix + "1\n")
+	if code := c.Run([]string{"tag"}); code != failure {
+		t.Fatalf("Expected tagging with a priority to fail, got %d", code)
+	}
+}
+
+// --- }}}
+
+// --- `elos todo new` {{{
+
+// TestTodoNew tests the `new` subcommand
+func TestTodoNew(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	// set up tasks
+	task1 := newTestTask(t, db, user)
+	task1.Name = "task1"
+	task2 := newTestTask(t, db, user)
+	task2.Name = "task2"
+	if err := db.Save(task1); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Save(task2); err != nil {
+		t.Fatal(err)
+	}
+
+	// Here we create 3 tasks, the first has a deadline and
+	// is called "top". "top" has "task1" and "task2" as
+	// prereqs. Then we create another prereq: 'sub'. 'sub'
+	// has no deadline, but has one prereq, another task
+	// we create, called 'bottom'
+	input := strings.Join([]string{
+		"top",    // Name
+		"y",      // Does it have a deadline
+		"2020",   // year
+		"1",      // month
+		"1",      // date
+		"12",     // hour
+		"0",      // minute
+		"n",      // estimate?
+		"y",      // prereqs?
+		"y",      // current tasks?
+		"0",      // index of prereq
+		"y",      // any more current prereqs?
+		"0",      // shouldn't error, even though already added
+		"y",      // any more current prereqs?
+		"1",      // now add `task2`
+		"n",      // any more current prereqs?
+		"y",      // any dependencies that are new?
+		"sub",    // name
+		"n",      // deadline
+		"n",      // estimate?
+		"y",      // prereqs?
+		"n",      // current?
+		"y",      // new?
+		"bottom", // name
+		"n",      //deadline?
+		"n",      //estimate?
+		"n",      //prereqs? => Task Created
+		"n",      // any more new prereqs? => TaskCreated
+		"n",      // any more new prereqs? => TaskCreated
+	}, "\n")
+
+	// load input
+	ui.InputReader = bytes.NewBufferString(input)
+
+	// run command
+	t.Log("running: `elos todo new`")
+	code := c.Run([]string{"new"})
+	t.Log("command 'new' terminated")
+
+	// basic checks
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// a small sample of keywords in output
+	if !strings.Contains(output, "Task created") {
+		t.Fatalf("Output should contain 'Task Created'")
+	}
+	if !strings.Contains(output, "deadline") {
+		t.Fatalf("Output should have contained 'deadline'")
+	}
+
+	// the summary is the tree of the tasks created
+	i := strings.Index(output, "Summary:")
+	if i < 0 {
+		t.Fatalf("Output should have contained a summary")
+	}
+	summary := output[i:]
+	for _, line := range []string{"\n* top (", "\n\t* task1\n", "\n\t* sub\n", "\n\t\t* bottom\n"} {
+		if !strings.Contains(summary, line) {
+			t.Fatalf("Summary should have contained %q", line)
+		}
+	}
+
+	// now to verify that the tasks were created
+	// first top
+	top := new(models.Task)
+	if err := db.PopulateByField("name", "top", top); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("top: %+v", top)
+
+	if len(top.PrerequisiteIds) != 3 {
+		t.Fatal("Expected 'top' to have 3 prereqs")
+	}
+
+	if top.DeadlineAt.Time().Year() != 2020 {
+		t.Fatal("Expected 'top' to have a deadline in 2020")
+	}
+
+	// then sub
+	sub := new(models.Task)
+	if err := db.PopulateByField("name", "sub", sub); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("'sub'")
+	t.Logf("%+v", sub)
+
+	if len(sub.PrerequisiteIds) != 1 {
+		t.Fatal("Expected 'top' to have 3 prereqs")
+	}
+
+	prereqs := make([]*models.Task, len(sub.PrerequisiteIds))
+	for i, id := range sub.PrerequisiteIds {
+		tsk := &models.Task{Id: id}
+		if err := db.PopulateByID(tsk); err != nil {
+			t.Fatalf("db.PopulateByID error: %v", err)
+		}
+		prereqs[i] = tsk
+	}
+
+	if len(prereqs) != 1 {
+		t.Fatal("The length of the prereqs should be 1")
+	}
+
+	if prereqs[0].Name != "bottom" {
+		t.Fatal("the prerequiste of 'sub' should have been 'bottom'")
+	}
+}
+
+// --- }}}
+
+// --- `elos todo prereqs` {{{
+
+// TestTodoPrereqs tests the `prereqs` subcommand
+func TestTodoPrereqs(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	// the prerequisites belong to another user, so that
+	// only top is listed for selection
+	other := newTestUserX(t, db)
+
+	done := newTestTask(t, db, other)
+	done.Name = "done"
+	task.StopAndComplete(done)
+	if err := db.Save(done); err != nil {
+		t.Fatal(err)
+	}
+
+	blocking := newTestTask(t, db, other)
+	blocking.Name = "blocking"
+	if err := db.Save(blocking); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted := newTestTask(t, db, other)
+	if err := db.Delete(deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	top := newTestTask(t, db, user)
+	top.Name = "top"
+	top.PrerequisiteIds = []string{done.Id, blocking.Id, deleted.Id}
+	if err := db.Save(top); err != nil {
+		t.Fatal(err)
+	}
+
+	ui.InputReader = bytes.NewBufferString("0\n")
+
+	t.Log("running: `elos todo prereqs`")
+	code := c.Run([]string{"prereqs"})
+	t.Log("command 'prereqs' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	if !strings.Contains(output, "* blocking") {
+		t.Fatalf("Output should have listed the incomplete prerequisite")
+	}
+
+	if strings.Contains(output, "* done") {
+		t.Fatalf("Output should not have listed the complete prerequisite")
+	}
+
+	if !strings.Contains(output, "unknown task") {
+		t.Fatalf("Output should have noted the deleted prerequisite")
+	}
+
+	if strings.Contains(output, "ready to start") {
+		t.Fatalf("Output should not have said the task is ready to start")
+	}
+}
+
+// --- }}}
+
+// ---	`elos todo start' & `elos todo stop` {{{
+
+// TestTodoStartStop tests the `start` and `stop` subcommands
+func TestTodoStartStop(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	// load a task into the db
+	tsk := newTestTask(t, db, user)
+
+	// load the input
+	ui.InputReader = bytes.NewBuffer([]byte("0\n"))
+
+	t.Log("running: `elos todo start`")
+	code := c.Run([]string{"start"})
+	t.Log("command 'start' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// verify some of the output
+	if !strings.Contains(output, "0)") {
+		t.Fatalf("Output should have contained a 0) for listing tasks")
+	}
+
+	if !strings.Contains(output, "Which number?") {
+		t.Fatalf("Output should have asked for a task number")
+	}
+
+	t.Log("Checking that the task was started")
+
+	if err := db.PopulateByID(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("Here's the task:\n%+v", tsk)
+
+	if !task.InProgress(tsk) {
+		t.Fatalf("Expected the task to in progress")
+	}
+
+	// get a fresh ui
+	ui = new(cli.MockUi)
+	c.UI = ui
+
+	// load the input
+	ui.InputReader = bytes.NewBuffer([]byte("0\n"))
+
+	t.Log("running: `elos todo stop`")
+	code = c.Run([]string{"stop"})
+	t.Log("command run terminated")
+
+	errput = ui.ErrorWriter.String()
+	output = ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// verify some of the output
+	if !strings.Contains(output, "0)") {
+		t.Fatalf("Output should have contained a 0) for listing tasks")
+	}
+
+	if !strings.Contains(output, "Which number?") {
+		t.Fatalf("Output should have asked for a task number")
+	}
+
+	t.Log("Checking that the task was stopped")
+
+	if err := db.PopulateByID(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("Here's the task:\n%+v", tsk)
+
+	if task.InProgress(tsk) {
+		t.Fatalf("Expected the task to _not_ in progress")
+	}
+}
+
+// TestTodoStopMalformedStages tests the `stop` subcommand on a task
+// which was started once, but whose stages were never paired
+func TestTodoStopMalformedStages(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	tsk := newTestTask(t, db, user)
+	tsk.Stages = []*models.Timestamp{models.TimestampFrom(time.Now().Add(-1 * time.Hour))}
+	if err := db.Save(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	// load the input
+	ui.InputReader = bytes.NewBuffer([]byte("0\n"))
+
+	t.Log("running: `elos todo stop`")
+	code := c.Run([]string{"stop"})
+	t.Log("command 'stop' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	if !strings.Contains(output, "You worked for") {
+		t.Fatalf("Output should have said how long the session was")
+	}
+
+	if err := db.PopulateByID(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	if task.InProgress(tsk) {
+		t.Fatalf("Expected the task to _not_ be in progress")
+	}
+}
+
+// --- }}}
+
+// --- `elos todo ready` & `elos todo waiting` {{{
+
+// newBlockedTasks seeds a task 'blocked', waiting on an incomplete
+// prerequisite, and a task 'ready', whose only prerequisite is complete
+func newBlockedTasks(t *testing.T, db data.DB, user *models.User) {
+	done := newTestTask(t, db, user)
+	done.Name = "done"
+	task.StopAndComplete(done)
+	if err := db.Save(done); err != nil {
+		t.Fatal(err)
+	}
+
+	blocking := newTestTask(t, db, user)
+	blocking.Name = "blocking"
+	if err := db.Save(blocking); err != nil {
+		t.Fatal(err)
+	}
+
+	blocked := newTestTask(t, db, user)
+	blocked.Name = "blocked"
+	blocked.PrerequisiteIds = []string{done.Id, blocking.Id}
+	if err := db.Save(blocked); err != nil {
+		t.Fatal(err)
+	}
+
+	ready := newTestTask(t, db, user)
+	ready.Name = "ready"
+	ready.PrerequisiteIds = []string{done.Id}
+	if err := db.Save(ready); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestTodoWaiting tests the `waiting` subcommand
+func TestTodoWaiting(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+	newBlockedTasks(t, db, user)
+
+	t.Log("running: `elos todo waiting`")
+	code := c.Run([]string{"waiting"})
+	t.Log("command 'waiting' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	if !strings.Contains(output, " blocked") {
+		t.Fatal("Expected the blocked task to be listed")
+	}
+
+	for _, name := range []string{" ready", " blocking"} {
+		if strings.Contains(output, name) {
+			t.Fatalf("Expected%s not to be listed", name)
+		}
+	}
+}
+
+// TestTodoReady tests the `ready` subcommand
+func TestTodoReady(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+	newBlockedTasks(t, db, user)
+
+	t.Log("running: `elos todo ready`")
+	code := c.Run([]string{"ready"})
+	t.Log("command 'ready' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	// blocking has no prerequisites, so it is ready too
+	for _, name := range []string{" ready", " blocking"} {
+		if !strings.Contains(output, name) {
+			t.Fatalf("Expected%s to be listed", name)
+		}
+	}
+
+	if strings.Contains(output, " blocked") {
+		t.Fatal("Expected the blocked task not to be listed")
+	}
+}
+
+// --- }}}
+
+// --- `elos todo review` {{{
+
+// TestTodoReview tests the `review` subcommand
+func TestTodoReview(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	names := []string{"one", "two", "three"}
+	for i, name := range names {
+		tsk := newTestTask(t, db, user)
+		tsk.Name = name
+		task.StopAndComplete(tsk)
+		tsk.CompletedAt = models.TimestampFrom(time.Now().AddDate(0, 0, -(i + 1)))
+		if err := db.Save(tsk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Log("running: `elos todo review -period week`")
+	code := c.Run([]string{"review", "-period", "week"})
+	t.Log("command 'review' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
 	}
 
-	if top.DeadlineAt.Time().Year() != 2020 {
-		t.Fatal("Expected 'top' to have a deadline in 2020")
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
 	}
 
-	// then sub
-	sub := new(models.Task)
-	if err := db.PopulateByField("name", "sub", sub); err != nil {
-		t.Fatal(err)
+	for _, name := range names {
+		if !strings.Contains(output, "* "+name) {
+			t.Fatalf("Expected '%s' to be listed", name)
+		}
 	}
 
-	t.Log("'sub'")
-	t.Logf("%+v", sub)
-
-	if len(sub.PrerequisiteIds) != 1 {
-		t.Fatal("Expected 'top' to have 3 prereqs")
+	// earliest completed first
+	if strings.Index(output, "* three") > strings.Index(output, "* one") {
+		t.Fatal("Expected the tasks in the order they were completed")
 	}
+}
 
-	prereqs := make([]*models.Task, len(sub.PrerequisiteIds))
-	for i, id := range sub.PrerequisiteIds {
-		tsk := &models.Task{Id: id}
-		if err := db.PopulateByID(tsk); err != nil {
-			t.Fatalf("db.PopulateByID error: %v", err)
-		}
-		prereqs[i] = tsk
-	}
+// TestTodoReviewBadPeriod tests that the `review` subcommand
+// rejects a period other than week or month
+func TestTodoReviewBadPeriod(t *testing.T) {
+	ui, _, _, c := newMockTodoCommand(t)
 
-	if len(prereqs) != 1 {
-		t.Fatal("The length of the prereqs should be 1")
+	if code := c.Run([]string{"review", "-period", "year"}); code != failure {
+		t.Fatalf("Expected failure, got %d", code)
 	}
 
-	if prereqs[0].Name != "bottom" {
-		t.Fatal("the prerequiste of 'sub' should have been 'bottom'")
+	if !strings.Contains(ui.ErrorWriter.String(), "unknown period") {
+		t.Fatal("Expected an error explaining the period is unknown")
 	}
 }
 
 // --- }}}
 
-// ---	`elos todo start' & `elos todo stop` {{{
+// --- `elos todo salience` {{{
 
-// TestTodoStartStop tests the `start` and `stop` subcommands
-func TestTodoStartStop(t *testing.T) {
+// TestTodoSalience tests the `salience` subcommand
+func TestTodoSalience(t *testing.T) {
 	ui, db, user, c := newMockTodoCommand(t)
 
-	// load a task into the db
-	tsk := newTestTask(t, db, user)
+	later := newTestTask(t, db, user)
+	later.Name = "later"
+	if err := db.Save(later); err != nil {
+		t.Fatal(err)
+	}
 
-	// load the input
-	ui.InputReader = bytes.NewBuffer([]byte("0\n"))
+	sooner := newTestTask(t, db, user)
+	sooner.Name = "sooner"
+	sooner.DeadlineAt = models.TimestampFrom(time.Now().Add(time.Hour))
+	tag.Task(sooner, "URGENT")
+	if err := db.Save(sooner); err != nil {
+		t.Fatal(err)
+	}
 
-	t.Log("running: `elos todo start`")
-	code := c.Run([]string{"start"})
-	t.Log("command 'start' terminated")
+	t.Log("running: `elos todo salience`")
+	code := c.Run([]string{"salience"})
+	t.Log("command 'salience' terminated")
 
 	errput := ui.ErrorWriter.String()
 	output := ui.OutputWriter.String()
@@ -809,40 +2323,51 @@ func TestTodoStartStop(t *testing.T) {
 		t.Fatalf("Expected successful exit code along with empty error output.")
 	}
 
-	// verify some of the output
-	if !strings.Contains(output, "0)") {
-		t.Fatalf("Output should have contained a 0) for listing tasks")
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and a row for each task, got %d lines", len(lines))
 	}
 
-	if !strings.Contains(output, "Which number?") {
-		t.Fatalf("Output should have asked for a task number")
+	if !strings.HasPrefix(lines[0], "#  SALIENCE") {
+		t.Fatalf("Expected the table header first, got: %s", lines[0])
 	}
 
-	t.Log("Checking that the task was started")
-
-	if err := db.PopulateByID(tsk); err != nil {
-		t.Fatal(err)
+	first, second := sooner, later
+	if task.Salience(later) > task.Salience(sooner) {
+		first, second = later, sooner
 	}
 
-	t.Logf("Here's the task:\n%+v", tsk)
+	if !strings.HasSuffix(lines[1], first.Name) || !strings.HasSuffix(lines[2], second.Name) {
+		t.Fatalf("Expected the most salient task, %s, first", first.Name)
+	}
 
-	if !task.InProgress(tsk) {
-		t.Fatalf("Expected the task to in progress")
+	if !strings.Contains(output, "URGENT") {
+		t.Fatal("Expected the table to list the task's tags")
 	}
+}
 
-	// get a fresh ui
-	ui = new(cli.MockUi)
-	c.UI = ui
+// --- }}}
 
-	// load the input
-	ui.InputReader = bytes.NewBuffer([]byte("0\n"))
+// --- `elos todo snooze` {{{
 
-	t.Log("running: `elos todo stop`")
-	code = c.Run([]string{"stop"})
-	t.Log("command run terminated")
+// TestTodoSnooze tests the `snooze` subcommand
+func TestTodoSnooze(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
 
-	errput = ui.ErrorWriter.String()
-	output = ui.OutputWriter.String()
+	deadline := time.Now().Add(time.Hour).Truncate(time.Second)
+	tsk := newTestTask(t, db, user)
+	tsk.Name = "snoozed"
+	tsk.DeadlineAt = models.TimestampFrom(deadline)
+	if err := db.Save(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("running: `elos todo snooze 0 3d`")
+	code := c.Run([]string{"snooze", "0", "3d"})
+	t.Log("command 'snooze' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
 	t.Logf("Error output:\n %s", errput)
 	t.Logf("Output:\n %s", output)
 
@@ -856,25 +2381,45 @@ func TestTodoStartStop(t *testing.T) {
 		t.Fatalf("Expected successful exit code along with empty error output.")
 	}
 
-	// verify some of the output
-	if !strings.Contains(output, "0)") {
-		t.Fatalf("Output should have contained a 0) for listing tasks")
+	if err := db.PopulateByID(tsk); err != nil {
+		t.Fatal(err)
 	}
 
-	if !strings.Contains(output, "Which number?") {
-		t.Fatalf("Output should have asked for a task number")
+	if got, want := tsk.DeadlineAt.Time(), deadline.Add(72*time.Hour); !got.Equal(want) {
+		t.Fatalf("Expected the deadline to move to %s, got %s", want, got)
 	}
 
-	t.Log("Checking that the task was stopped")
+	if !strings.Contains(output, "Snoozed 'snoozed'") {
+		t.Fatal("Expected the new deadline to be reported")
+	}
+}
 
-	if err := db.PopulateByID(tsk); err != nil {
+// TestTodoSnoozeNoDeadline tests that snoozing a task
+// without a deadline sets it to the duration from now
+func TestTodoSnoozeNoDeadline(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	tsk := newTestTask(t, db, user)
+	tsk.Name = "snoozed"
+	if err := db.Save(tsk); err != nil {
 		t.Fatal(err)
 	}
 
-	t.Logf("Here's the task:\n%+v", tsk)
+	ui.InputReader = bytes.NewBufferString("0\n2w\n")
 
-	if task.InProgress(tsk) {
-		t.Fatalf("Expected the task to _not_ in progress")
+	before := time.Now()
+	if code := c.Run([]string{"snooze"}); code != success {
+		t.Fatalf("Expected successful exit code, got %d: %s", code, ui.ErrorWriter.String())
+	}
+	after := time.Now()
+
+	if err := db.PopulateByID(tsk); err != nil {
+		t.Fatal(err)
+	}
+
+	week := 7 * 24 * time.Hour
+	if got := tsk.DeadlineAt.Time(); got.Before(before.Add(2*week).Truncate(time.Second)) || got.After(after.Add(2*week)) {
+		t.Fatalf("Expected the deadline to be two weeks from now, got %s", got)
 	}
 }
 
@@ -940,6 +2485,63 @@ func TestTodoSuggest(t *testing.T) {
 	}
 }
 
+// TestTodoSuggestWeighted tests that the `suggest` subcommand
+// favors tasks carrying a weighted tag
+func TestTodoSuggestWeighted(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+	c.Config = &Config{
+		TagWeights: map[string]float64{"URGENT": 1000},
+	}
+
+	pressing := newTestTask(t, db, user)
+	pressing.Name = "pressing"
+	pressing.DeadlineAt = models.TimestampFrom(time.Now().Add(time.Hour))
+	if err := db.Save(pressing); err != nil {
+		t.Fatal(err)
+	}
+
+	flagged := newTestTask(t, db, user)
+	flagged.Name = "flagged"
+	flagged.DeadlineAt = models.TimestampFrom(time.Now().Add(7 * 24 * time.Hour))
+	tag.Task(flagged, "URGENT")
+	if err := db.Save(flagged); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := task.Salience(flagged); s <= 0 || s >= task.Salience(pressing) {
+		t.Fatalf("Expected the URGENT task to be less salient, before weighting")
+	}
+
+	ui.InputReader = bytes.NewBufferString("n\n") // no, don't start the task
+
+	t.Log("running: `elos todo suggest`")
+	code := c.Run([]string{"suggest"})
+	t.Log("command 'suggest' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	if !strings.Contains(output, "flagged") {
+		t.Fatal("Expected the URGENT task to be suggested")
+	}
+
+	if strings.Contains(output, "pressing") {
+		t.Fatal("Expected the more salient, but unweighted, task not to be suggested")
+	}
+}
+
 // --- }}}
 
 // --- `elos todo tag` {{{
@@ -1013,6 +2615,76 @@ func TestTodoTag(t *testing.T) {
 	}
 }
 
+// TestTodoTagAll tests the `tag` subcommand with the -all flag,
+// tagging two tasks at once
+func TestTodoTagAll(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	for _, name := range []string{"Take out the trash", "Write the report"} {
+		tsk := newTestTask(t, db, user)
+		tsk.Name = name
+		if err := db.Save(tsk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Log("running: `elos todo tag -all IMPORTED`")
+	code := c.Run([]string{"tag", "-all", "IMPORTED"})
+	t.Log("command 'tag -all' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	if !strings.Contains(output, "Tagged 2 tasks") {
+		t.Fatalf("Output should have said two tasks were tagged")
+	}
+
+	tasks, err := tag.TasksFor(db, c.UserID, "IMPORTED")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tasks) != 2 {
+		t.Fatalf("Expected the tag to contain two tasks, contained: %d", len(tasks))
+	}
+}
+
+// TestTodoTagAllAlreadyTagged tests that `tag -all` only
+// counts the tasks which didn't already carry the tag
+func TestTodoTagAllAlreadyTagged(t *testing.T) {
+	ui, db, user, c := newMockTodoCommand(t)
+
+	tagged := newTestTask(t, db, user)
+	tag.Task(tagged, "IMPORTED")
+	if err := db.Save(tagged); err != nil {
+		t.Fatal(err)
+	}
+	newTestTask(t, db, user)
+
+	if code := c.Run([]string{"tag", "-all", "IMPORTED"}); code != success {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	output := ui.OutputWriter.String()
+	t.Logf("Output:\n %s", output)
+
+	if !strings.Contains(output, "Tagged 1 tasks") {
+		t.Fatal("Output should have said only one task was tagged")
+	}
+}
+
 // TestTodoTag tests the `elos todo tag -r` subcommand with the
 // "r" flag
 func TestTodoTagRemove(t *testing.T) {
@@ -1144,9 +2816,162 @@ func TestTodoToday(t *testing.T) {
 
 // --- }}}
 
+// --- `elos todo weight` {{{
+
+// TestTodoWeight tests the `weight` subcommand
+func TestTodoWeight(t *testing.T) {
+	f, err := ioutil.TempFile("", "conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.Close()
+
+	ui, _, _, c := newMockTodoCommand(t)
+	c.Config = &Config{Path: f.Name()}
+
+	t.Log("running: `elos todo weight URGENT 2.0`")
+	code := c.Run([]string{"weight", "URGENT", "2.0"})
+	t.Log("command 'weight' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	conf, err := ParseConfigFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w := conf.TagWeights["URGENT"]; w != 2.0 {
+		t.Fatalf("Expected the persisted weight of URGENT to be 2, got %g", w)
+	}
+
+	t.Log("running: `elos todo weight`")
+	if code := c.Run([]string{"weight"}); code != success {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	if !strings.Contains(ui.OutputWriter.String(), "URGENT: 2") {
+		t.Fatal("Expected the weight of URGENT to be listed")
+	}
+}
+
+// TestTodoWeightInvalid tests that the `weight` subcommand
+// rejects weights which aren't positive numbers
+func TestTodoWeightInvalid(t *testing.T) {
+	for _, w := range []string{"heavy", "0", "-1"} {
+		ui, _, _, c := newMockTodoCommand(t)
+		c.Config = &Config{}
+
+		if code := c.Run([]string{"weight", "URGENT", w}); code != failure {
+			t.Fatalf("Expected a weight of %q to fail", w)
+		}
+
+		if !strings.Contains(ui.ErrorWriter.String(), "not a positive number") {
+			t.Fatalf("Expected an error explaining %q is not a positive number", w)
+		}
+
+		if len(c.Config.TagWeights) != 0 {
+			t.Fatalf("Expected no weight to be set for %q", w)
+		}
+	}
+}
+
+// --- }}}
+
 // --- }}}
 
 // --- Internals {{{
+
+func TestNeedsTasks(t *testing.T) {
+	for _, test := range []struct {
+		args  []string
+		needs bool
+	}{
+		{[]string{"clean"}, false},
+		{[]string{"import"}, false},
+		{[]string{"review"}, false},
+		{[]string{"today"}, false},
+		{[]string{"weight"}, false},
+		{[]string{"list", "-completed"}, false},
+		{[]string{"list", "-completed", "30d"}, false},
+		{[]string{"list"}, true},
+		{[]string{"list", "-t"}, true},
+		{[]string{"suggest"}, true},
+		{[]string{"garbage"}, true},
+	} {
+		if got := needsTasks(test.args); got != test.needs {
+			t.Errorf("needsTasks(%q) = %t, expected %t", test.args, got, test.needs)
+		}
+	}
+}
+
+// TestTodoTodaySkipsTasks tests that the tasks aren't
+// loaded for a subcommand which doesn't need them
+func TestTodoTodaySkipsTasks(t *testing.T) {
+	_, db, user, c := newMockTodoCommand(t)
+	newTestTask(t, db, user)
+
+	if code := c.Run([]string{"today"}); code != success {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	if c.tasks != nil {
+		t.Fatalf("Expected the tasks not to be loaded, got %d", len(c.tasks))
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	instant := time.Date(2016, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	if got, want := formatTime(instant, time.FixedZone("UTC-5", -5*60*60)), "Thu Mar 10 07:00"; got != want {
+		t.Fatalf("formatTime: got %q, want %q", got, want)
+	}
+
+	if got, want := formatTime(instant, nil), instant.Local().Format(dateFormat); got != want {
+		t.Fatalf("formatTime with nil location: got %q, want %q", got, want)
+	}
+}
+
+// TestDependsOn tests that a prerequisite which would form a
+// cycle, here of two tasks, is detected
+func TestDependsOn(t *testing.T) {
+	_, db, user, c := newMockTodoCommand(t)
+
+	a := newTestTask(t, db, user)
+	b := newTestTask(t, db, user)
+	b.PrerequisiteIds = []string{a.Id}
+	if err := db.Save(b); err != nil {
+		t.Fatal(err)
+	}
+
+	// b depends on a, so a can't depend on b
+	if cycle, err := c.dependsOn(b.Id, a.Id); err != nil {
+		t.Fatal(err)
+	} else if !cycle {
+		t.Fatal("Expected making b a prerequisite of a to form a cycle")
+	}
+
+	// but b can depend on a
+	if cycle, err := c.dependsOn(a.Id, b.Id); err != nil {
+		t.Fatal(err)
+	} else if cycle {
+		t.Fatal("Expected making a a prerequisite of b not to form a cycle")
+	}
+}
+
 // --- }}}
 
 // --- }}}
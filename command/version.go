@@ -0,0 +1,50 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// Version is the version of the elos command line interface,
+// used by every CLI constructed, and printed by 'elos version'
+const Version = "0.1.0"
+
+// VersionCommand contains the state necessary to implement the
+// 'elos version' command.
+//
+// It implements the cli.Command interface
+type VersionCommand struct {
+	// UI is used to communicate (for IO) with the user
+	// It must be non-nil
+	UI cli.Ui
+}
+
+// Synopsis is a one-line, short summary of the 'version' command.
+// It is guaranteed to be at most 50 characters.
+func (c *VersionCommand) Synopsis() string {
+	return "Print the version of elos"
+}
+
+// Help is the long-form help text for the 'version' command.
+func (c *VersionCommand) Help() string {
+	helpText := `
+Usage:
+	elos version
+
+	Prints the version of the elos command line interface.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Run runs the 'version' command. It returns an exit status when it
+// finishes. 0 indicates a success, any other integer indicates a failure.
+func (c *VersionCommand) Run(args []string) int {
+	if c.UI == nil {
+		return failure
+	}
+
+	c.UI.Output(fmt.Sprintf("elos v%s", Version))
+	return success
+}
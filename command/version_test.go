@@ -0,0 +1,20 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestVersion(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &VersionCommand{UI: ui}
+
+	if code := c.Run(nil); code != success {
+		t.Fatalf("Expected successful exit code, got %d", code)
+	}
+
+	if got, want := ui.OutputWriter.String(), "elos v"+Version+"\n"; got != want {
+		t.Fatalf("Expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,120 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/elos/x/data"
+	models "github.com/elos/x/models/proto"
+	"github.com/mitchellh/cli"
+)
+
+// whoamiTimeout is how long 'elos whoami' waits on the database
+// before deciding it is offline
+const whoamiTimeout = 5 * time.Second
+
+// WhoamiCommand contains the state necessary to implement the
+// 'elos whoami' command.
+//
+// It implements the cli.Command interface
+type WhoamiCommand struct {
+	// UI is used to communicate (for IO) with the user
+	// It must be non-nil
+	UI cli.Ui
+
+	// UserID is the id of the user we are acting on behalf of.
+	UserID string
+
+	data.DBClient
+}
+
+// Synopsis is a one-line, short summary of the 'whoami' command.
+// It is guaranteed to be at most 50 characters.
+func (c *WhoamiCommand) Synopsis() string {
+	return "Print the user you are acting as"
+}
+
+// Help is the long-form help text for the 'whoami' command.
+func (c *WhoamiCommand) Help() string {
+	helpText := `
+Usage:
+	elos whoami
+
+	Prints the configured user id and, if elos is reachable,
+	the username of that user.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// Run runs the 'whoami' command. It returns an exit status when it
+// finishes. 0 indicates a success, any other integer indicates a failure.
+func (c *WhoamiCommand) Run(args []string) int {
+	if c.UI == nil {
+		return failure
+	}
+
+	if c.UserID == "" {
+		c.UI.Warn("You are not acting as any user, see 'elos setup'")
+		return failure
+	}
+
+	c.UI.Output(fmt.Sprintf("User ID: %s", c.UserID))
+
+	// the username is a nicety, so failing to find it isn't a failure
+	if c.DBClient == nil {
+		return success
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), whoamiTimeout)
+	defer cancel()
+
+	username, err := c.username(ctx)
+	switch {
+	case err != nil:
+		c.UI.Warn(fmt.Sprintf("Could not look up your username (offline?): %s", err))
+	case username == "":
+		c.UI.Warn("No username found")
+	default:
+		c.UI.Output(fmt.Sprintf("Username: %s", username))
+	}
+
+	return success
+}
+
+// username retrieves the public part of the user's password credential,
+// or the empty string if the user has no password credential.
+func (c *WhoamiCommand) username(ctx context.Context) (string, error) {
+	results, err := c.DBClient.Query(ctx, &data.Query{
+		Kind: models.Kind_CREDENTIAL,
+		Filters: []*data.Filter{
+			&data.Filter{
+				Op:    data.Filter_EQ,
+				Field: "owner_id",
+				Reference: &models.Value{
+					Type:    models.Value_STRING,
+					String_: c.UserID,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		r, err := results.Recv()
+		if err == io.EOF {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if r.Credential.Type == models.Credential_PASSWORD {
+			return r.Credential.Public, results.CloseSend()
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package command
+
+import (
+	"context"
+	"testing"
+
+	"github.com/elos/data/builtin/mem"
+	"github.com/elos/x/data"
+	"github.com/mitchellh/cli"
+)
+
+func TestWhoami(t *testing.T) {
+	cases := map[string]struct {
+		userID   string
+		code     int
+		err, out string
+	}{
+		"elos whoami": {
+			userID: "1",
+			out:    "User ID: 1\nUsername: pu\n",
+		},
+		"elos whoami (no credential)": {
+			userID: "9",
+			out:    "User ID: 9\n",
+			err:    "No username found\n",
+		},
+		"elos whoami (no user)": {
+			code: failure,
+			err:  "You are not acting as any user, see 'elos setup'\n",
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			db := mem.NewDB()
+			dbc, conn, err := data.DBBothLocal(ctx, db)
+			if err != nil {
+				t.Fatalf("data.DBBothLocal error: %v", err)
+			}
+			defer conn.Close()
+			if err := data.Seed(context.Background(), dbc, countState()); err != nil {
+				t.Fatalf("data.Seed error: %v", err)
+			}
+
+			ui := new(cli.MockUi)
+			cmd := &WhoamiCommand{
+				UI:       ui,
+				UserID:   c.userID,
+				DBClient: dbc,
+			}
+
+			if got, want := cmd.Run([]string{}), c.code; got != want {
+				t.Fatalf("cmd.Run: got %d, want %d", got, want)
+			}
+
+			if got, want := ui.ErrorWriter.String(), c.err; got != want {
+				t.Fatalf("ui.ErrorWriter.String(): got %q, want %q", got, want)
+			}
+
+			if got, want := ui.OutputWriter.String(), c.out; got != want {
+				t.Fatalf("ui.OutputWriter.String(): got %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -2,7 +2,6 @@ package command
 
 import (
 	"fmt"
-	"log"
 	"sort"
 	"strings"
 	"time"
@@ -46,8 +45,14 @@ func (c *XCommand) Help() string {
 Usage:
 	elos x <subcommand>
 
-	review		review things
-	`
+Subcommands:
+	review taskweek	list the tasks completed in the last week
+	tasktime [options]	list the time spent on the tasks of each tag
+
+Options (tasktime):
+	-tag <name>	only the tag with the name
+	-since <Nd|date>	only the tasks completed in the last N days, or since the date
+`
 	return strings.TrimSpace(helpText)
 }
 
@@ -57,45 +62,135 @@ func (c *XCommand) Run(args []string) int {
 		return success
 	}
 
+	if c.UI == nil {
+		// can't use errorf, because the UI is not defined
+		return failure
+	}
+
 	switch args[0] {
 	case "review":
 		return c.runReview(args)
 	case "tasktime":
 		return c.runTaskTime(args)
+	default:
+		c.UI.Output(c.Help())
 	}
 
 	return success
 }
 
+// errorf calls UI.Error with a formatted, prefixed error string
+// always use it to print an error, avoid using UI.Error directly
+func (c *XCommand) errorf(format string, values ...interface{}) {
+	c.UI.Error(fmt.Sprintf("(elos x) Error: "+format, values...))
+}
+
+// runTaskTime prints the time spent on the tasks of each tag, and in
+// total. With -tag it is only that tag, and with -since (i.e., 7d) only
+// the tasks completed since then are counted.
 func (c *XCommand) runTaskTime(args []string) int {
+	name, args, err := stringFlag(args[1:], "tag", "")
+	if err != nil {
+		c.errorf("%s", err)
+		c.UI.Output(c.Help())
+		return failure
+	}
+
+	window, args, err := stringFlag(args, "since", "")
+	if err != nil {
+		c.errorf("%s", err)
+		c.UI.Output(c.Help())
+		return failure
+	}
+
+	if len(args) > 0 {
+		c.errorf("unrecognized arguments %q", args)
+		c.UI.Output(c.Help())
+		return failure
+	}
+
+	// the zero time counts every task
+	var since time.Time
+	if window != "" {
+		if since, err = parseSince(window, time.Now()); err != nil {
+			c.errorf("%s", err)
+			return failure
+		}
+	}
+
 	db := c.DB
-	iter, _ := db.Query(models.TagKind).Select(data.AttrMap{"owner_id": c.UserID}).Execute()
+	iter, err := db.Query(models.TagKind).Select(data.AttrMap{"owner_id": c.UserID}).Execute()
+	if err != nil {
+		c.errorf("querying tags: %s", err)
+		return failure
+	}
+
+	// a task with many tags counts once toward the total
+	var total time.Duration
+	counted := make(map[data.ID]bool)
+	found := false
 
 	t := models.NewTag()
 	for iter.Next(t) {
-		tasks, _ := tag.TasksFor(db, t)
+		if name != "" && t.Name != name {
+			continue
+		}
+		found = true
+
+		tasks, err := tag.TasksFor(db, t)
+		if err != nil {
+			iter.Close()
+			c.errorf("retrieving the tasks of %s: %s", t.Name, err)
+			return failure
+		}
 
 		var totalTime time.Duration
 		for _, t := range tasks {
-			totalTime += task.TimeSpent(t)
+			if !since.IsZero() && t.CompletedAt.Before(since) {
+				continue
+			}
+
+			spent := task.TimeSpent(t)
+			totalTime += spent
+
+			if !counted[t.ID()] {
+				counted[t.ID()] = true
+				total += spent
+			}
 		}
 
 		c.UI.Output(t.Name)
 		c.UI.Output(fmt.Sprintf("\t%s", totalTime))
 	}
 
-	iter.Close()
+	if err := iter.Close(); err != nil {
+		c.errorf("querying tags: %s", err)
+		return failure
+	}
+
+	if name != "" && !found {
+		c.errorf("no tag named %q", name)
+		return failure
+	}
+
+	c.UI.Output(fmt.Sprintf("Total: %s", total))
 
 	return success
 }
 
 func (c *XCommand) runReview(args []string) int {
+	if len(args) < 2 {
+		c.errorf("review requires something to review, i.e., 'review taskweek'")
+		c.UI.Output(c.Help())
+		return failure
+	}
+
 	db := c.DB
 	switch args[1] {
 	case "taskweek":
 		iter, err := db.Query(models.TaskKind).Select(data.AttrMap{"owner_id": c.UserID}).Execute()
 		if err != nil {
-			log.Fatal(err)
+			c.errorf("querying tasks: %s", err)
 			return failure
 		}
 
@@ -107,8 +202,15 @@ func (c *XCommand) runReview(args []string) int {
 		for iter.Next(t) {
 			if t.CompletedAt.Local().After(oneWeekAgo.Local()) {
 				completedInLastWeek = append(completedInLastWeek, t)
-				t = models.NewTask()
 			}
+
+			// always a new task, so that no appended task is overwritten
+			t = models.NewTask()
+		}
+
+		if err := iter.Close(); err != nil {
+			c.errorf("querying tasks: %s", err)
+			return failure
 		}
 
 		if len(completedInLastWeek) == 0 {
@@ -121,7 +223,11 @@ func (c *XCommand) runReview(args []string) int {
 		for _, t := range completedInLastWeek {
 			c.UI.Output(fmt.Sprintf("\t* %s [%s]", t.Name, t.CompletedAt.Local().Format("Mon Jan 2")))
 		}
-
+	default:
+		c.errorf("can't review %q", args[1])
+		c.UI.Output(c.Help())
+		return failure
 	}
+
 	return success
 }
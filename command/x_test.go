@@ -0,0 +1,234 @@
+package command
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elos/data/builtin/mem"
+	"github.com/elos/models"
+	"github.com/mitchellh/cli"
+)
+
+// --- `elos x review taskweek` {{{
+
+// TestXReviewTaskWeek tests that every task completed in the last week
+// is listed, even when read between tasks which weren't
+func TestXReviewTaskWeek(t *testing.T) {
+	ui := new(cli.MockUi)
+	db := mem.NewDB()
+	user := newTestUser(t, db)
+
+	c := &XCommand{
+		UI:     ui,
+		UserID: user.ID().String(),
+		DB:     db,
+	}
+
+	completed := map[string]time.Time{
+		"recent-1": time.Now().Add(-1 * 24 * time.Hour),
+		"old-1":    time.Now().Add(-30 * 24 * time.Hour),
+		"recent-2": time.Now().Add(-2 * 24 * time.Hour),
+		"old-2":    time.Now().Add(-60 * 24 * time.Hour),
+		"recent-3": time.Now().Add(-3 * 24 * time.Hour),
+	}
+
+	// interleave the matching and non-matching tasks
+	for _, name := range []string{"recent-1", "old-1", "recent-2", "old-2", "recent-3"} {
+		tsk := models.NewTask()
+		tsk.SetID(db.NewID())
+		tsk.CreatedAt = time.Now()
+		tsk.OwnerId = c.UserID
+		tsk.Name = name
+		tsk.CompletedAt = completed[name]
+		tsk.UpdatedAt = time.Now()
+		if err := db.Save(tsk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Log("running: `elos x review taskweek`")
+	code := c.Run([]string{"review", "taskweek"})
+	t.Log("command 'review taskweek' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	for _, name := range []string{"recent-1", "recent-2", "recent-3"} {
+		if strings.Count(output, "* "+name+" ") != 1 {
+			t.Fatalf("Expected '%s' to be listed exactly once", name)
+		}
+	}
+
+	if strings.Contains(output, "old-") {
+		t.Fatal("Expected no task completed over a week ago to be listed")
+	}
+}
+
+// TestXReviewTaskWeekCloseError tests that an error querying the
+// tasks is reported, and fails the command, rather than exiting
+func TestXReviewTaskWeekCloseError(t *testing.T) {
+	ui := new(cli.MockUi)
+	db := mem.NewDB()
+	user := newTestUser(t, db)
+
+	c := &XCommand{
+		UI:     ui,
+		UserID: user.ID().String(),
+		DB:     closeErrDB{db},
+	}
+
+	if code := c.Run([]string{"review", "taskweek"}); code != failure {
+		t.Fatalf("Expected failure exit code, got %d", code)
+	}
+
+	if errput := ui.ErrorWriter.String(); !strings.Contains(errput, closeErr.Error()) {
+		t.Fatalf("Expected the error to contain the cause, got: %s", errput)
+	}
+}
+
+// --- }}}
+
+// --- `elos x tasktime` {{{
+
+// TestXTaskTimeTag tests that `tasktime -tag` restricts to the tag
+func TestXTaskTimeTag(t *testing.T) {
+	ui := new(cli.MockUi)
+	db := mem.NewDB()
+	user := newTestUser(t, db)
+
+	c := &XCommand{
+		UI:     ui,
+		UserID: user.ID().String(),
+		DB:     db,
+	}
+
+	for _, name := range []string{"work", "play"} {
+		tg := models.NewTag()
+		tg.SetID(db.NewID())
+		tg.CreatedAt = time.Now()
+		tg.OwnerId = c.UserID
+		tg.Name = name
+		tg.UpdatedAt = time.Now()
+		if err := db.Save(tg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Log("running: `elos x tasktime -tag work -since 7d`")
+	code := c.Run([]string{"tasktime", "-tag", "work", "-since", "7d"})
+	t.Log("command 'tasktime' terminated")
+
+	errput := ui.ErrorWriter.String()
+	output := ui.OutputWriter.String()
+	t.Logf("Error output:\n %s", errput)
+	t.Logf("Output:\n %s", output)
+
+	// verify there were no errors
+	if errput != "" {
+		t.Fatalf("Expected no error output, got: %s", errput)
+	}
+
+	// verify success
+	if code != success {
+		t.Fatalf("Expected successful exit code along with empty error output.")
+	}
+
+	if !strings.Contains(output, "work") {
+		t.Fatal("Expected the work tag to be listed")
+	}
+
+	if strings.Contains(output, "play") {
+		t.Fatal("Expected only the work tag to be listed")
+	}
+
+	if !strings.Contains(output, "Total: 0s") {
+		t.Fatal("Expected the total time spent to be printed")
+	}
+}
+
+// TestXTaskTimeErrors tests that `tasktime` rejects bad flags
+func TestXTaskTimeErrors(t *testing.T) {
+	cases := map[string][]string{
+		"no tag named":            {"tasktime", "-tag", "missing"},
+		"-since requires a value": {"tasktime", "-since"},
+		"unrecognized arguments":  {"tasktime", "extra"},
+	}
+
+	for msg, args := range cases {
+		ui := new(cli.MockUi)
+		db := mem.NewDB()
+		user := newTestUser(t, db)
+
+		c := &XCommand{
+			UI:     ui,
+			UserID: user.ID().String(),
+			DB:     db,
+		}
+
+		if code := c.Run(args); code != failure {
+			t.Fatalf("Expected %q to fail, got %d", args, code)
+		}
+
+		if !strings.Contains(ui.ErrorWriter.String(), msg) {
+			t.Fatalf("Expected the error for %q to contain %q, got: %s", args, msg, ui.ErrorWriter.String())
+		}
+	}
+}
+
+// --- }}}
+
+// --- `elos x` missing arguments {{{
+
+// TestXMissingArgs tests that the subcommands print help,
+// rather than panicking, when missing arguments
+func TestXMissingArgs(t *testing.T) {
+	for _, args := range [][]string{
+		{"review"},
+		{"review", "everything"},
+		{"tasktime", "-tag"},
+		{"unknown"},
+	} {
+		ui := new(cli.MockUi)
+		db := mem.NewDB()
+		user := newTestUser(t, db)
+
+		c := &XCommand{
+			UI:     ui,
+			UserID: user.ID().String(),
+			DB:     db,
+		}
+
+		t.Logf("running: `elos x %s`", strings.Join(args, " "))
+		c.Run(args)
+
+		if !strings.Contains(ui.OutputWriter.String(), "Usage:") {
+			t.Fatalf("Expected `elos x %s` to print the help", strings.Join(args, " "))
+		}
+	}
+}
+
+// TestXHelp tests that the help lists every subcommand
+func TestXHelp(t *testing.T) {
+	help := (&XCommand{}).Help()
+
+	for _, sub := range []string{"review", "taskweek", "tasktime"} {
+		if !strings.Contains(help, sub) {
+			t.Fatalf("Expected the help to list %q", sub)
+		}
+	}
+}
+
+// --- }}}
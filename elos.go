@@ -3,15 +3,17 @@ package main
 import (
 	"os"
 
+	"github.com/elos/elos/command"
 	"github.com/mitchellh/cli"
 )
 
 func main() {
 	// Construct a new CLI with our name and version
-	c := cli.NewCLI("elos", "0.1.0")
+	c := cli.NewCLI("elos", command.Version)
 
-	// Pass along all the arguments from the operating system
-	c.Args = os.Args[1:]
+	// Pass along all the arguments from the operating system,
+	// except --no-color, which applies to every command
+	c.Args, command.Color = command.ParseColor(os.Args[1:], os.Stdout)
 
 	// Configure the commands (var 'Commands' is defined in init.go)
 	c.Commands = Commands
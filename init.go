@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/user"
 	"path"
+	"time"
 
 	"google.golang.org/grpc"
 
@@ -44,6 +45,12 @@ func init() {
 
 	Configuration = c
 
+	location, err := Configuration.Location()
+	if err != nil {
+		UI.Warn(fmt.Sprintf("Unknown time zone %q, using local time: %s", Configuration.Timezone, err))
+		location = time.Local
+	}
+
 	var db olddata.DB
 	var databaseError error
 
@@ -54,12 +61,17 @@ func init() {
 			databaseError = fmt.Errorf("No database listed")
 		}
 	} else {
-		db = &gaia.DB{
+		timeout, err := Configuration.HTTPTimeout()
+		if err != nil {
+			UI.Warn(fmt.Sprintf("Invalid timeout %q, requests won't time out: %s", Configuration.Timeout, err))
+		}
+
+		db = command.NewRetryDB(&gaia.DB{
 			URL:      Configuration.Host,
 			Username: Configuration.PublicCredential,
 			Password: Configuration.PrivateCredential,
-			Client:   new(http.Client),
-		}
+			Client:   &http.Client{Timeout: timeout},
+		}, Configuration.Retries)
 	}
 	conn, err := grpc.Dial(
 		"elos.pw:4444",
@@ -79,6 +91,13 @@ func init() {
 	dbc := data.NewDBClient(conn)
 
 	Commands = map[string]cli.CommandFactory{
+		"event": func() (cli.Command, error) {
+			return &command.EventCommand{
+				UI:     UI,
+				UserID: Configuration.UserID,
+				DB:     db,
+			}, databaseError
+		},
 		"habit": func() (cli.Command, error) {
 			return &command.HabitCommand{
 				UI:     UI,
@@ -86,6 +105,13 @@ func init() {
 				DB:     db,
 			}, databaseError
 		},
+		"note": func() (cli.Command, error) {
+			return &command.NoteCommand{
+				UI:     UI,
+				UserID: Configuration.UserID,
+				DB:     db,
+			}, databaseError
+		},
 		"people": func() (cli.Command, error) {
 			return &command.PeopleCommand{
 				UI:     UI,
@@ -93,6 +119,18 @@ func init() {
 				DB:     db,
 			}, databaseError
 		},
+		"completion": func() (cli.Command, error) {
+			return &command.CompletionCommand{
+				UI:       UI,
+				Commands: Commands,
+			}, nil
+		},
+		"auth": func() (cli.Command, error) {
+			return &command.AuthCommand{
+				UI:     UI,
+				Config: Configuration,
+			}, nil
+		},
 		"setup": func() (cli.Command, error) {
 			return &command.SetupCommand{
 				UI:     UI,
@@ -115,9 +153,11 @@ func init() {
 		},
 		"todo": func() (cli.Command, error) {
 			return &command.TodoCommand{
-				UI:     UI,
-				UserID: Configuration.Credential.OwnerID,
-				DB:     data.DB(dbc),
+				UI:       UI,
+				UserID:   Configuration.Credential.OwnerID,
+				DB:       data.DB(dbc),
+				Location: location,
+				Config:   Configuration,
 			}, databaseError
 		},
 		"cal2": func() (cli.Command, error) {
@@ -125,6 +165,19 @@ func init() {
 				UI:       UI,
 				UserID:   Configuration.Credential.OwnerID,
 				DBClient: dbc,
+				Location: location,
+			}, nil
+		},
+		"version": func() (cli.Command, error) {
+			return &command.VersionCommand{
+				UI: UI,
+			}, nil
+		},
+		"whoami": func() (cli.Command, error) {
+			return &command.WhoamiCommand{
+				UI:       UI,
+				UserID:   Configuration.Credential.OwnerID,
+				DBClient: dbc,
 			}, nil
 		},
 		"records": func() (cli.Command, error) {